@@ -66,7 +66,7 @@ func evaluateBinaryOperatorNode[T, E any](node *asts.ASTNode, numeric Numeric[T,
 	case "-":
 		return numeric.Subtract(a, b), nil
 	case "*":
-		return numeric.Multiply(a, b), nil
+		return numeric.Multiply(a, b)
 	case "/":
 		return numeric.Divide(a, b)
 	case "%":
@@ -7,13 +7,14 @@ const maxExponentInt = 0x7fffffff
 // exponent type for Exponentiate (e.g. int for modular, same as T for int).
 // ParseExponent parses an exponent from a literal string (e.g. for ** right-hand
 // side). For f2poly/f2polymod the exponent is decimal; for others it matches FromString.
+// Multiply fails only when the product is not representable (F2Poly past degree 63).
 type Numeric[T, E any] interface {
 	FromString(s string) (T, error)
 	ParseExponent(s string) (E, error)
 	String(t T) string
 	Add(a, b T) T
 	Subtract(a, b T) T
-	Multiply(a, b T) T
+	Multiply(a, b T) (T, error)
 	Divide(a, b T) (T, error)
 	Mod(a, b T) (T, error)
 	Exponentiate(base T, exp E) (T, error)
@@ -41,7 +41,10 @@ func (F2PolyNumeric) String(t *f2poly.F2Poly) string {
 
 func (F2PolyNumeric) Add(a, b *f2poly.F2Poly) *f2poly.F2Poly      { return a.Add(b) }
 func (F2PolyNumeric) Subtract(a, b *f2poly.F2Poly) *f2poly.F2Poly { return a.Sub(b) }
-func (F2PolyNumeric) Multiply(a, b *f2poly.F2Poly) *f2poly.F2Poly { return a.Mul(b) }
+
+func (F2PolyNumeric) Multiply(a, b *f2poly.F2Poly) (*f2poly.F2Poly, error) {
+	return a.MulChecked(b)
+}
 
 func (F2PolyNumeric) Divide(a, b *f2poly.F2Poly) (*f2poly.F2Poly, error) {
 	if b.Bits == 0 {
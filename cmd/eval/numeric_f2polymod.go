@@ -52,7 +52,9 @@ func (b *F2PolyModNumeric) String(t *f2polymod.F2PolyMod) string {
 
 func (b *F2PolyModNumeric) Add(a, c *f2polymod.F2PolyMod) *f2polymod.F2PolyMod      { return a.Add(c) }
 func (b *F2PolyModNumeric) Subtract(a, c *f2polymod.F2PolyMod) *f2polymod.F2PolyMod { return a.Sub(c) }
-func (b *F2PolyModNumeric) Multiply(a, c *f2polymod.F2PolyMod) *f2polymod.F2PolyMod { return a.Mul(c) }
+func (b *F2PolyModNumeric) Multiply(a, c *f2polymod.F2PolyMod) (*f2polymod.F2PolyMod, error) {
+	return a.Mul(c), nil
+}
 
 func (b *F2PolyModNumeric) Divide(a, c *f2polymod.F2PolyMod) (*f2polymod.F2PolyMod, error) {
 	return a.Div(c)
@@ -22,9 +22,9 @@ func (IntNumeric) String(t int) string {
 	return strconv.Itoa(t)
 }
 
-func (IntNumeric) Add(a, b int) int      { return a + b }
-func (IntNumeric) Subtract(a, b int) int { return a - b }
-func (IntNumeric) Multiply(a, b int) int { return a * b }
+func (IntNumeric) Add(a, b int) int               { return a + b }
+func (IntNumeric) Subtract(a, b int) int          { return a - b }
+func (IntNumeric) Multiply(a, b int) (int, error) { return a * b, nil }
 
 func (IntNumeric) Divide(a, b int) (int, error) {
 	if b == 0 {
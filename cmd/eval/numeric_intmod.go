@@ -40,7 +40,9 @@ func (b *IntModNumeric) String(t *intmod.IntMod) string {
 
 func (b *IntModNumeric) Add(a, c *intmod.IntMod) *intmod.IntMod      { return a.Add(c) }
 func (b *IntModNumeric) Subtract(a, c *intmod.IntMod) *intmod.IntMod { return a.Sub(c) }
-func (b *IntModNumeric) Multiply(a, c *intmod.IntMod) *intmod.IntMod { return a.Mul(c) }
+func (b *IntModNumeric) Multiply(a, c *intmod.IntMod) (*intmod.IntMod, error) {
+	return a.Mul(c), nil
+}
 
 func (b *IntModNumeric) Divide(a, c *intmod.IntMod) (*intmod.IntMod, error) {
 	if c.Residue == 0 {
@@ -56,8 +56,8 @@ func (b *ModNumeric) Subtract(a, bVal ModInt) ModInt {
 	return b.normalize(a.V - bVal.V)
 }
 
-func (b *ModNumeric) Multiply(a, bVal ModInt) ModInt {
-	return b.normalize(a.V * bVal.V)
+func (b *ModNumeric) Multiply(a, bVal ModInt) (ModInt, error) {
+	return b.normalize(a.V * bVal.V), nil
 }
 
 func (b *ModNumeric) Divide(a, bVal ModInt) (ModInt, error) {
@@ -35,3 +35,23 @@ func TestF2PolyModPow2Mod13(t *testing.T) {
 		}
 	}
 }
+
+// TestF2PolyProductOverflow verifies that an F2Poly product past degree 63 is an error
+// rather than silently truncated.
+func TestF2PolyProductOverflow(t *testing.T) {
+	var b F2PolyNumeric
+	for expr, ok := range map[string]bool{
+		"8000000000000000 * 1":  true,
+		"100000000 * 80000000":  true,
+		"100000000 * 100000000": false,
+	} {
+		ast, err := parseWithMode(expr, "f2poly")
+		if err != nil {
+			t.Fatalf("parse %q: %v", expr, err)
+		}
+		_, err = evaluateAST[*f2poly.F2Poly, int](ast, b, false)
+		if (err == nil) != ok {
+			t.Errorf("eval %q: err = %v, want ok %v", expr, err, ok)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package bitarith
+
+import "testing"
+
+// The MAGIC-style implementations bitarith used before switching to math/bits, kept as
+// references for the equivalence tests and benchmarks.
+
+func magicOnes32(x uint32) int {
+	x = (x & 0x55555555) + ((x >> 1) & 0x55555555)
+	x = (x & 0x33333333) + ((x >> 2) & 0x33333333)
+	x = (x & 0x0F0F0F0F) + ((x >> 4) & 0x0F0F0F0F)
+	x = (x & 0x00FF00FF) + ((x >> 8) & 0x00FF00FF)
+	x = (x & 0x0000FFFF) + ((x >> 16) & 0x0000FFFF)
+	return int(x)
+}
+
+func magicFloorLog2_32(x uint32) int {
+	x |= x >> 1
+	x |= x >> 2
+	x |= x >> 4
+	x |= x >> 8
+	x |= x >> 16
+	return magicOnes32(x) - 1
+}
+
+func magicMsbPos(x uint64) int {
+	if x == 0 {
+		return -1
+	}
+	count := 0
+	for {
+		word := uint32(x & 0xFFFFFFFF)
+		x >>= 32
+		p := magicFloorLog2_32(word)
+		if x == 0 {
+			return p + count
+		}
+		count += 32
+	}
+}
+
+func magicMsb(x uint64) uint64 {
+	shiftAmount := uint(1)
+	xshift := x >> shiftAmount
+	for xshift > 0 {
+		x |= xshift
+		shiftAmount <<= 1
+		xshift = x >> shiftAmount
+	}
+	return x & ^(x >> 1)
+}
+
+func magicOnes(x uint64) int {
+	count := 0
+	for x != 0 {
+		count += magicOnes32(uint32(x & 0xFFFFFFFF))
+		x >>= 32
+	}
+	return count
+}
+
+func magicExactLog2(x uint64) int {
+	if x == 0 {
+		return -1
+	}
+	count := 0
+	for {
+		word := uint32(x & 0xFFFFFFFF)
+		if word != 0 {
+			return count + magicFloorLog2_32(word)
+		}
+		x >>= 32
+		count += 32
+	}
+}
+
+func magicLsbPos(x uint64) int {
+	if x == 0 {
+		return -1
+	}
+	return magicExactLog2(Lsb(x))
+}
+
+// testWords returns n pseudorandom words of varied density, plus edge cases.
+func testWords(n int) []uint64 {
+	words := []uint64{0, 1, 2, 3, 1 << 31, 1 << 32, 1 << 63, ^uint64(0), 0xDEADBEEF, 0xDEADBEEF << 32}
+	state := uint64(0x9E3779B97F4A7C15)
+	for len(words) < n {
+		state = state*6364136223846793005 + 1442695040888963407
+		x := state
+		switch len(words) % 3 {
+		case 1:
+			x >>= state & 63
+		case 2:
+			x &= x >> 17
+		}
+		words = append(words, x)
+	}
+	return words
+}
+
+func TestAgainstMagic(t *testing.T) {
+	for _, x := range testWords(10000) {
+		if got, want := MsbPos(x), magicMsbPos(x); got != want {
+			t.Fatalf("MsbPos(0x%X) = %d, want %d", x, got, want)
+		}
+		if got, want := Msb(x), magicMsb(x); got != want {
+			t.Fatalf("Msb(0x%X) = 0x%X, want 0x%X", x, got, want)
+		}
+		if got, want := Ones(x), magicOnes(x); got != want {
+			t.Fatalf("Ones(0x%X) = %d, want %d", x, got, want)
+		}
+		if got, want := ExactLog2(x), magicExactLog2(x); got != want {
+			t.Fatalf("ExactLog2(0x%X) = %d, want %d", x, got, want)
+		}
+		if got, want := LsbPos(x), magicLsbPos(x); got != want {
+			t.Fatalf("LsbPos(0x%X) = %d, want %d", x, got, want)
+		}
+		x32 := uint32(x)
+		if got, want := FloorLog2_32(x32), magicFloorLog2_32(x32); got != want {
+			t.Fatalf("FloorLog2_32(0x%X) = %d, want %d", x32, got, want)
+		}
+		if got, want := Ones32(x32), magicOnes32(x32); got != want {
+			t.Fatalf("Ones32(0x%X) = %d, want %d", x32, got, want)
+		}
+		if got, want := LsbPos32(x32), magicFloorLog2_32(Lsb32(x32)); got != want {
+			t.Fatalf("LsbPos32(0x%X) = %d, want %d", x32, got, want)
+		}
+	}
+}
+
+var sink int
+var sink64 uint64
+
+func benchmarkInt(b *testing.B, f func(uint64) int) {
+	words := testWords(1024)
+	for b.Loop() {
+		for _, x := range words {
+			sink += f(x)
+		}
+	}
+}
+
+func benchmarkWord(b *testing.B, f func(uint64) uint64) {
+	words := testWords(1024)
+	for b.Loop() {
+		for _, x := range words {
+			sink64 ^= f(x)
+		}
+	}
+}
+
+func BenchmarkMsbPos(b *testing.B) {
+	b.Run("magic", func(b *testing.B) { benchmarkInt(b, magicMsbPos) })
+	b.Run("bits", func(b *testing.B) { benchmarkInt(b, MsbPos) })
+}
+
+func BenchmarkLsbPos(b *testing.B) {
+	b.Run("magic", func(b *testing.B) { benchmarkInt(b, magicLsbPos) })
+	b.Run("bits", func(b *testing.B) { benchmarkInt(b, LsbPos) })
+}
+
+func BenchmarkOnes(b *testing.B) {
+	b.Run("magic", func(b *testing.B) { benchmarkInt(b, magicOnes) })
+	b.Run("bits", func(b *testing.B) { benchmarkInt(b, Ones) })
+}
+
+func BenchmarkExactLog2(b *testing.B) {
+	b.Run("magic", func(b *testing.B) { benchmarkInt(b, magicExactLog2) })
+	b.Run("bits", func(b *testing.B) { benchmarkInt(b, ExactLog2) })
+}
+
+func BenchmarkMsb(b *testing.B) {
+	b.Run("magic", func(b *testing.B) { benchmarkWord(b, magicMsb) })
+	b.Run("bits", func(b *testing.B) { benchmarkWord(b, Msb) })
+}
+
+func BenchmarkPdepPext(b *testing.B) {
+	const mask = 0x0F0F_00FF_8001_F00F
+	b.Run("pdep", func(b *testing.B) { benchmarkWord(b, func(x uint64) uint64 { return Pdep(x, mask) }) })
+	b.Run("pext", func(b *testing.B) { benchmarkWord(b, func(x uint64) uint64 { return Pext(x, mask) }) })
+}
+
+func BenchmarkMorton(b *testing.B) {
+	benchmarkWord(b, func(x uint64) uint64 { return MortonInterleave(uint32(x), uint32(x>>32)) })
+}
+
+func benchmarkClMul(b *testing.B, f func(x, y uint64) (uint64, uint64)) {
+	words := testWords(1024)
+	for b.Loop() {
+		for i, x := range words {
+			hi, lo := f(x, words[1023-i])
+			sink64 ^= hi ^ lo
+		}
+	}
+}
+
+func BenchmarkClMul(b *testing.B) {
+	b.Run("naive", func(b *testing.B) { benchmarkClMul(b, clMulNaive) })
+	b.Run("nibble", func(b *testing.B) { benchmarkClMul(b, ClMul) })
+	b.Run("square", func(b *testing.B) {
+		benchmarkClMul(b, func(x, _ uint64) (uint64, uint64) { return ClSquare(x) })
+	})
+}
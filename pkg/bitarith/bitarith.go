@@ -1,14 +1,15 @@
 // Package bitarith provides bit-manipulation utilities (msb, lsb, popcount, floor_log2, etc.).
-// Algorithms mostly due to aggregate.org/MAGIC
+// The basics are backed by math/bits, which the compiler turns into single instructions
+// where the hardware has them; the rest are mostly due to aggregate.org/MAGIC.
 package bitarith
 
+import "math/bits"
+
 func Msb32(x uint32) uint32 {
-	x |= x >> 1
-	x |= x >> 2
-	x |= x >> 4
-	x |= x >> 8
-	x |= x >> 16
-	return x & ^(x >> 1)
+	if x == 0 {
+		return 0
+	}
+	return 1 << (31 - bits.LeadingZeros32(x))
 }
 
 func Lsb32(x uint32) uint32 {
@@ -16,56 +17,37 @@ func Lsb32(x uint32) uint32 {
 }
 
 func Ones32(x uint32) int {
-	x = (x & 0x55555555) + ((x >> 1) & 0x55555555)
-	x = (x & 0x33333333) + ((x >> 2) & 0x33333333)
-	x = (x & 0x0F0F0F0F) + ((x >> 4) & 0x0F0F0F0F)
-	x = (x & 0x00FF00FF) + ((x >> 8) & 0x00FF00FF)
-	x = (x & 0x0000FFFF) + ((x >> 16) & 0x0000FFFF)
-	return int(x)
+	return bits.OnesCount32(x)
 }
 
+// FloorLog2_32 returns floor(log2(x)), or -1 for zero.
 func FloorLog2_32(x uint32) int {
-	x |= x >> 1
-	x |= x >> 2
-	x |= x >> 4
-	x |= x >> 8
-	x |= x >> 16
-	return Ones32(x) - 1
+	return bits.Len32(x) - 1
 }
 
+// MsbPos32 returns the position of the highest set bit, or -1 for zero.
 func MsbPos32(x uint32) int {
-	return FloorLog2_32(Msb32(x))
+	return bits.Len32(x) - 1
 }
 
+// LsbPos32 returns the position of the lowest set bit, or -1 for zero.
 func LsbPos32(x uint32) int {
-	return FloorLog2_32(Lsb32(x))
-}
-
-func MsbPos(x uint64) int {
 	if x == 0 {
 		return -1
 	}
-	count := 0
-	for {
-		word := uint32(x & 0xFFFFFFFF)
-		x >>= 32
-		p := MsbPos32(word)
-		if x == 0 {
-			return p + count
-		}
-		count += 32
-	}
+	return bits.TrailingZeros32(x)
+}
+
+// MsbPos returns the position of the highest set bit, or -1 for zero.
+func MsbPos(x uint64) int {
+	return bits.Len64(x) - 1
 }
 
 func Msb(x uint64) uint64 {
-	shiftAmount := uint(1)
-	xshift := x >> shiftAmount
-	for xshift > 0 {
-		x |= xshift
-		shiftAmount <<= 1
-		xshift = x >> shiftAmount
+	if x == 0 {
+		return 0
 	}
-	return x & ^(x >> 1)
+	return 1 << (63 - bits.LeadingZeros64(x))
 }
 
 func Lsb(x uint64) uint64 {
@@ -73,36 +55,111 @@ func Lsb(x uint64) uint64 {
 }
 
 func Ones(x uint64) int {
-	if x == 0 {
-		return 0
-	}
-	count := 0
-	for x != 0 {
-		word := uint32(x & 0xFFFFFFFF)
-		x >>= 32
-		count += Ones32(word)
-	}
-	return count
+	return bits.OnesCount64(x)
 }
 
+// ExactLog2 returns log2(x) for x a power of two, or -1 for zero. For other x it returns
+// the floor of log2 of the lowest nonzero 32-bit half, offset by its position.
 func ExactLog2(x uint64) int {
 	if x == 0 {
 		return -1
 	}
-	count := 0
-	for {
-		word := uint32(x & 0xFFFFFFFF)
-		if word != 0 {
-			return count + FloorLog2_32(word)
-		}
-		x >>= 32
-		count += 32
+	if lo := uint32(x); lo != 0 {
+		return bits.Len32(lo) - 1
 	}
+	return 32 + bits.Len32(uint32(x>>32)) - 1
 }
 
+// LsbPos returns the position of the lowest set bit, or -1 for zero.
 func LsbPos(x uint64) int {
 	if x == 0 {
 		return -1
 	}
-	return ExactLog2(Lsb(x))
+	return bits.TrailingZeros64(x)
+}
+
+// Parity returns 1 if x has an odd number of set bits, else 0.
+func Parity(x uint64) int {
+	return bits.OnesCount64(x) & 1
+}
+
+// Reverse returns x with bit i moved to bit 63-i.
+func Reverse(x uint64) uint64 {
+	return bits.Reverse64(x)
+}
+
+// Reverse32 returns x with bit i moved to bit 31-i.
+func Reverse32(x uint32) uint32 {
+	return bits.Reverse32(x)
+}
+
+// ByteSwap returns x with its bytes in reverse order.
+func ByteSwap(x uint64) uint64 {
+	return bits.ReverseBytes64(x)
+}
+
+// ByteSwap32 returns x with its bytes in reverse order.
+func ByteSwap32(x uint32) uint32 {
+	return bits.ReverseBytes32(x)
+}
+
+// spreadBits moves bit i of x to bit 2i.
+func spreadBits(x uint32) uint64 {
+	z := uint64(x)
+	z = (z | z<<16) & 0x0000FFFF0000FFFF
+	z = (z | z<<8) & 0x00FF00FF00FF00FF
+	z = (z | z<<4) & 0x0F0F0F0F0F0F0F0F
+	z = (z | z<<2) & 0x3333333333333333
+	z = (z | z<<1) & 0x5555555555555555
+	return z
+}
+
+// gatherBits moves bit 2i of z to bit i, ignoring the odd bits.
+func gatherBits(z uint64) uint32 {
+	z &= 0x5555555555555555
+	z = (z | z>>1) & 0x3333333333333333
+	z = (z | z>>2) & 0x0F0F0F0F0F0F0F0F
+	z = (z | z>>4) & 0x00FF00FF00FF00FF
+	z = (z | z>>8) & 0x0000FFFF0000FFFF
+	z = (z | z>>16) & 0x00000000FFFFFFFF
+	return uint32(z)
+}
+
+// MortonInterleave returns the Morton code of (x, y): bit i of x goes to bit 2i and bit
+// i of y to bit 2i+1.
+func MortonInterleave(x, y uint32) uint64 {
+	return spreadBits(x) | spreadBits(y)<<1
+}
+
+// MortonDeinterleave is the inverse of MortonInterleave.
+func MortonDeinterleave(z uint64) (x, y uint32) {
+	return gatherBits(z), gatherBits(z >> 1)
+}
+
+// Pdep deposits the low bits of x, in order, at the set positions of mask, like the x86
+// PDEP instruction.
+func Pdep(x, mask uint64) uint64 {
+	var rv uint64
+	for bit := uint64(1); mask != 0; bit <<= 1 {
+		lowest := mask & -mask
+		if x&bit != 0 {
+			rv |= lowest
+		}
+		mask ^= lowest
+	}
+	return rv
+}
+
+// Pext extracts the bits of x at the set positions of mask and packs them, in order, into
+// the low bits of the result, like the x86 PEXT instruction.
+func Pext(x, mask uint64) uint64 {
+	var rv uint64
+	for bit := uint64(1); mask != 0; bit <<= 1 {
+		lowest := mask & -mask
+		if x&lowest != 0 {
+			rv |= bit
+		}
+		mask ^= lowest
+	}
+	return rv
 }
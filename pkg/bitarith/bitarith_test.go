@@ -81,3 +81,248 @@ func TestLsbPos(t *testing.T) {
 		t.Errorf("LsbPos(0x0C) = %d, want 2", got)
 	}
 }
+
+func TestParity(t *testing.T) {
+	tests := []struct {
+		x    uint64
+		want int
+	}{
+		{0, 0},
+		{1, 1},
+		{3, 0},
+		{0xDEADBEEF, 0},
+		{1 << 63, 1},
+	}
+	for _, tt := range tests {
+		if got := Parity(tt.x); got != tt.want {
+			t.Errorf("Parity(0x%X) = %d, want %d", tt.x, got, tt.want)
+		}
+	}
+}
+
+func TestReverseAndByteSwap(t *testing.T) {
+	if got := Reverse(1); got != 1<<63 {
+		t.Errorf("Reverse(1) = 0x%X", got)
+	}
+	if got := Reverse(0x13); got != 0xC800000000000000 {
+		t.Errorf("Reverse(0x13) = 0x%X", got)
+	}
+	if got := Reverse32(0x13); got != 0xC8000000 {
+		t.Errorf("Reverse32(0x13) = 0x%X", got)
+	}
+	if got := ByteSwap(0x0102030405060708); got != 0x0807060504030201 {
+		t.Errorf("ByteSwap = 0x%X", got)
+	}
+	if got := ByteSwap32(0xDEADBEEF); got != 0xEFBEADDE {
+		t.Errorf("ByteSwap32 = 0x%X", got)
+	}
+}
+
+func TestMorton(t *testing.T) {
+	tests := []struct {
+		x, y uint32
+		want uint64
+	}{
+		{0, 0, 0},
+		{1, 0, 1},
+		{0, 1, 2},
+		{3, 0, 5},
+		{0xFFFFFFFF, 0, 0x5555555555555555},
+		{0, 0xFFFFFFFF, 0xAAAAAAAAAAAAAAAA},
+	}
+	for _, tt := range tests {
+		z := MortonInterleave(tt.x, tt.y)
+		if z != tt.want {
+			t.Errorf("MortonInterleave(0x%X, 0x%X) = 0x%X, want 0x%X", tt.x, tt.y, z, tt.want)
+		}
+		if x, y := MortonDeinterleave(z); x != tt.x || y != tt.y {
+			t.Errorf("MortonDeinterleave(0x%X) = 0x%X, 0x%X", z, x, y)
+		}
+	}
+	for _, w := range testWords(1000) {
+		x, y := uint32(w), uint32(w>>32)
+		z := MortonInterleave(x, y)
+		for i := 0; i < 32; i++ {
+			if (z>>(2*i))&1 != uint64(x>>i)&1 || (z>>(2*i+1))&1 != uint64(y>>i)&1 {
+				t.Fatalf("MortonInterleave(0x%X, 0x%X): bit %d misplaced", x, y, i)
+			}
+		}
+		if gx, gy := MortonDeinterleave(z); gx != x || gy != y {
+			t.Fatalf("MortonDeinterleave(0x%X) = 0x%X, 0x%X", z, gx, gy)
+		}
+	}
+}
+
+func TestPdepPext(t *testing.T) {
+	tests := []struct {
+		x, mask, dep, ext uint64
+	}{
+		{0, 0xFF, 0, 0},
+		{0xFF, 0, 0, 0},
+		{0b101, 0b11010, 0b10010, 0b0},
+		{0b111, 0xF0, 0x70, 0},
+		{0xF0, 0xF0, 0, 0xF},
+		{^uint64(0), 1 << 63, 1 << 63, 1},
+	}
+	for _, tt := range tests {
+		if got := Pdep(tt.x, tt.mask); got != tt.dep {
+			t.Errorf("Pdep(0x%X, 0x%X) = 0x%X, want 0x%X", tt.x, tt.mask, got, tt.dep)
+		}
+		if got := Pext(tt.x, tt.mask); got != tt.ext {
+			t.Errorf("Pext(0x%X, 0x%X) = 0x%X, want 0x%X", tt.x, tt.mask, got, tt.ext)
+		}
+	}
+	// Pext undoes Pdep, and Pdep undoes Pext on the masked bits.
+	for _, x := range testWords(1000) {
+		mask := x ^ (x >> 7) ^ 0x00FF00FF00FF00FF
+		if got := Pext(Pdep(x, mask), mask); got != x&(1<<Ones(mask)-1) {
+			t.Fatalf("Pext(Pdep(0x%X)) = 0x%X", x, got)
+		}
+		if got := Pdep(Pext(x, mask), mask); got != x&mask {
+			t.Fatalf("Pdep(Pext(0x%X)) = 0x%X", x, got)
+		}
+	}
+}
+
+// clMulNaive is the shift-and-XOR loop ClMul replaces.
+func clMulNaive(a, b uint64) (hi, lo uint64) {
+	for j := 0; j < 64; j++ {
+		if (b>>j)&1 == 1 {
+			lo ^= a << j
+			if j > 0 {
+				hi ^= a >> (64 - j)
+			}
+		}
+	}
+	return hi, lo
+}
+
+func TestClMul(t *testing.T) {
+	tests := []struct {
+		a, b, hi, lo uint64
+	}{
+		{0, 0xFF, 0, 0},
+		{1, 0xDEADBEEF, 0, 0xDEADBEEF},
+		{0x3, 0x3, 0, 0x5},
+		{0x13, 0x13, 0, 0x105},
+		{1 << 63, 1 << 63, 1 << 62, 0},
+		{^uint64(0), 0x3, 1, 1},
+	}
+	for _, tt := range tests {
+		if hi, lo := ClMul(tt.a, tt.b); hi != tt.hi || lo != tt.lo {
+			t.Errorf("ClMul(0x%X, 0x%X) = 0x%X:0x%X, want 0x%X:0x%X", tt.a, tt.b, hi, lo, tt.hi, tt.lo)
+		}
+	}
+	words := testWords(300)
+	for i, a := range words {
+		b := words[(7*i+3)%len(words)]
+		hi, lo := ClMul(a, b)
+		if wantHi, wantLo := clMulNaive(a, b); hi != wantHi || lo != wantLo {
+			t.Fatalf("ClMul(0x%X, 0x%X) = 0x%X:0x%X, want 0x%X:0x%X", a, b, hi, lo, wantHi, wantLo)
+		}
+		if bhi, blo := ClMul(b, a); bhi != hi || blo != lo {
+			t.Fatalf("ClMul(0x%X, 0x%X) not commutative", a, b)
+		}
+		sqHi, sqLo := ClSquare(a)
+		if wantHi, wantLo := ClMul(a, a); sqHi != wantHi || sqLo != wantLo {
+			t.Fatalf("ClSquare(0x%X) = 0x%X:0x%X, want 0x%X:0x%X", a, sqHi, sqLo, wantHi, wantLo)
+		}
+	}
+}
+
+func TestBinomial(t *testing.T) {
+	tests := []struct {
+		n, k int
+		want uint64
+	}{
+		{0, 0, 1},
+		{5, 2, 10},
+		{10, 11, 0},
+		{64, 1, 64},
+		{64, 32, 1832624140942590534},
+		{65, 1, 0},
+	}
+	for _, tt := range tests {
+		if got := Binomial(tt.n, tt.k); got != tt.want {
+			t.Errorf("Binomial(%d, %d) = %d, want %d", tt.n, tt.k, got, tt.want)
+		}
+	}
+}
+
+func TestFixedWeight(t *testing.T) {
+	for n := 0; n <= 12; n++ {
+		for k := 0; k <= n; k++ {
+			var got []uint64
+			for x := range FixedWeight(n, k) {
+				got = append(got, x)
+			}
+			var want []uint64
+			for x := uint64(0); x < 1<<n; x++ {
+				if Ones(x) == k {
+					want = append(want, x)
+				}
+			}
+			if len(got) != len(want) {
+				t.Fatalf("FixedWeight(%d, %d): %d words, want %d", n, k, len(got), len(want))
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("FixedWeight(%d, %d)[%d] = 0x%X, want 0x%X", n, k, i, got[i], want[i])
+				}
+				if r := CombinationRank(got[i]); r != uint64(i) {
+					t.Fatalf("CombinationRank(0x%X) = %d, want %d", got[i], r, i)
+				}
+				if x, ok := CombinationUnrank(uint64(i), k); !ok || x != got[i] {
+					t.Fatalf("CombinationUnrank(%d, %d) = 0x%X, want 0x%X", i, k, x, got[i])
+				}
+			}
+		}
+	}
+
+	// The full width ends with the top k bits and does not run past them.
+	count := 0
+	var last uint64
+	for x := range FixedWeight(64, 2) {
+		count++
+		last = x
+	}
+	if count != 2016 || last != 3<<62 {
+		t.Errorf("FixedWeight(64, 2): %d words ending 0x%X", count, last)
+	}
+	var all []uint64
+	for x := range FixedWeight(64, 64) {
+		all = append(all, x)
+	}
+	if len(all) != 1 || all[0] != ^uint64(0) {
+		t.Errorf("FixedWeight(64, 64) = %X", all)
+	}
+	if x, ok := CombinationUnrank(Binomial(64, 3)-1, 3); !ok || x != 7<<61 {
+		t.Errorf("last rank of weight 3 unranks to 0x%X", x)
+	}
+	if _, ok := CombinationUnrank(Binomial(64, 3), 3); ok {
+		t.Error("rank past the end should fail")
+	}
+}
+
+func TestGrayCode(t *testing.T) {
+	prev := uint64(0)
+	i := uint64(0)
+	for g, pos := range GrayCodes(10) {
+		if g != GrayCode(i) || GrayRank(g) != i {
+			t.Fatalf("step %d: got 0x%X, GrayCode 0x%X", i, g, GrayCode(i))
+		}
+		if i > 0 && (g^prev != 1<<pos || Ones(g^prev) != 1) {
+			t.Fatalf("step %d: 0x%X -> 0x%X reported bit %d", i, prev, g, pos)
+		}
+		prev = g
+		i++
+	}
+	if i != 1<<10 {
+		t.Errorf("GrayCodes(10) yielded %d words", i)
+	}
+	for _, r := range testWords(1000) {
+		if GrayRank(GrayCode(r)) != r {
+			t.Fatalf("GrayRank(GrayCode(0x%X)) != 0x%X", r, r)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package bitarith
+
+// Carry-less (GF(2)[x]) multiplication: like integer multiplication but adding partial
+// products with XOR, so bit i of a word is the coefficient of x^i.
+
+// ClMul returns the 128-bit carry-less product of a and b as its high and low words.
+// It works four bits of b at a time from a table of a times each nibble.
+func ClMul(a, b uint64) (hi, lo uint64) {
+	if a == 0 || b == 0 {
+		return 0, 0
+	}
+	var tableLo, tableHi [16]uint64
+	tableLo[1] = a
+	for k := 2; k < 16; k += 2 {
+		tableLo[k] = tableLo[k/2] << 1
+		tableHi[k] = tableHi[k/2]<<1 | tableLo[k/2]>>63
+		tableLo[k+1] = tableLo[k] ^ a
+		tableHi[k+1] = tableHi[k]
+	}
+	for shift := MsbPos(b) &^ 3; shift >= 0; shift -= 4 {
+		hi = hi<<4 | lo>>60
+		lo <<= 4
+		nibble := (b >> shift) & 0xf
+		lo ^= tableLo[nibble]
+		hi ^= tableHi[nibble]
+	}
+	return hi, lo
+}
+
+// ClSquare returns the 128-bit carry-less square of a as its high and low words. Over
+// GF(2) squaring just moves bit i to bit 2i.
+func ClSquare(a uint64) (hi, lo uint64) {
+	return spreadBits(uint32(a >> 32)), spreadBits(uint32(a))
+}
@@ -0,0 +1,126 @@
+package bitarith
+
+import "iter"
+
+// Enumerators for search loops: words of fixed weight, Gray-code order, and the
+// combinatorial number system that ranks fixed-weight words.
+
+// binomials[n][k] is n choose k for 0 <= k <= n <= 64; the largest, 64 choose 32, fits
+// in a uint64.
+var binomials = func() [65][65]uint64 {
+	var c [65][65]uint64
+	for n := 0; n <= 64; n++ {
+		c[n][0] = 1
+		for k := 1; k <= n; k++ {
+			c[n][k] = c[n-1][k-1] + c[n-1][k]
+		}
+	}
+	return c
+}()
+
+// Binomial returns n choose k for 0 <= n <= 64, and 0 for k out of range.
+func Binomial(n, k int) uint64 {
+	if n < 0 || n > 64 || k < 0 || k > n {
+		return 0
+	}
+	return binomials[n][k]
+}
+
+// FixedWeight yields the words below 2^n with exactly k bits set, in increasing order,
+// for 0 <= k <= n <= 64. Each step is Gosper's hack. Other arguments yield nothing.
+func FixedWeight(n, k int) iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		if n < 0 || n > 64 || k < 0 || k > n {
+			return
+		}
+		if k == 0 {
+			yield(0)
+			return
+		}
+		x := ^uint64(0) >> (64 - k)
+		last := x << (n - k)
+		for {
+			if !yield(x) || x == last {
+				return
+			}
+			c := x & -x
+			r := x + c
+			x = (((r ^ x) >> 2) / c) | r
+		}
+	}
+}
+
+// GrayCode returns the word at position r of the binary reflected Gray code. Consecutive
+// words differ in exactly one bit.
+func GrayCode(r uint64) uint64 {
+	return r ^ (r >> 1)
+}
+
+// GrayRank is the inverse of GrayCode: it returns the position of g in the Gray code.
+func GrayRank(g uint64) uint64 {
+	g ^= g >> 1
+	g ^= g >> 2
+	g ^= g >> 4
+	g ^= g >> 8
+	g ^= g >> 16
+	g ^= g >> 32
+	return g
+}
+
+// GrayCodes yields all words below 2^n, 0 <= n <= 64, in Gray-code order starting from 0,
+// each with the position of the bit that changed from the previous word (-1 for the
+// first).
+func GrayCodes(n int) iter.Seq2[uint64, int] {
+	return func(yield func(uint64, int) bool) {
+		if n < 0 || n > 64 {
+			return
+		}
+		if !yield(0, -1) {
+			return
+		}
+		g := uint64(0)
+		for r := uint64(1); n == 64 || r < 1<<n; r++ {
+			// The bit that changes at step r is the lowest set bit of r.
+			pos := LsbPos(r)
+			if pos < 0 {
+				return // r wrapped past 2^64 - 1
+			}
+			g ^= 1 << pos
+			if !yield(g, pos) {
+				return
+			}
+		}
+	}
+}
+
+// CombinationRank returns the position of x among the words of its weight in increasing
+// order, as yielded by FixedWeight. With the set bits c_1 < ... < c_k of x, it is the sum
+// of c_i choose i.
+func CombinationRank(x uint64) uint64 {
+	var rank uint64
+	for i := 1; x != 0; i++ {
+		rank += binomials[LsbPos(x)][i]
+		x &= x - 1
+	}
+	return rank
+}
+
+// CombinationUnrank is the inverse of CombinationRank: it returns the word of weight k at
+// position rank, or false if rank is at least 64 choose k.
+func CombinationUnrank(rank uint64, k int) (uint64, bool) {
+	if k < 0 || k > 64 || rank >= binomials[64][k] {
+		return 0, false
+	}
+	var x uint64
+	c := 63
+	for i := k; i >= 1; i-- {
+		// Take the largest c with c choose i at most what is left.
+		for binomials[c][i] > rank {
+			c--
+		}
+		x |= 1 << c
+		rank -= binomials[c][i]
+		c--
+	}
+	return x, true
+}
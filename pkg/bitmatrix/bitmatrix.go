@@ -6,6 +6,7 @@ import (
 )
 
 // BitMatrix is a matrix of bit vectors over GF(2). Supports row echelon form and kernel basis.
+// Rows are multiword bit vectors, so there is no limit on the number of columns.
 type BitMatrix struct {
 	numRows, numCols int
 	Rows             []*bitvector.BitVector
@@ -34,34 +35,20 @@ func (m *BitMatrix) SetBinaryOutput() { bitvector.SetBinaryOutput() }
 
 func (m *BitMatrix) Row(i int) *bitvector.BitVector { return m.Rows[i] }
 
-// RowEchelonForm reduces the matrix to row echelon form in-place.
+// RowEchelonForm reduces the matrix to reduced row echelon form in-place.
 func (m *BitMatrix) RowEchelonForm() {
-	m.rowReduceBelow()
-	for row := 0; row < m.numRows; row++ {
-		for row2 := row + 1; row2 < m.numRows; row2++ {
-			row2LeaderPos := m.Rows[row2].FindLeaderPos()
-			if row2LeaderPos < 0 {
-				break
-			}
-			rowLeaderVal, _ := m.Rows[row].Get(row2LeaderPos)
-			if rowLeaderVal == 0 {
-				continue
-			}
-			m.Rows[row].Bits ^= m.Rows[row2].Bits
-		}
-	}
+	m.reducedRowEchelon(nil)
 }
 
 func (m *BitMatrix) Rank() int {
 	rr := m.clone()
-	rr.rowReduceBelow()
-	return rr.RankRR()
+	return len(rr.rowReduceBelow(nil, nil))
 }
 
 func (m *BitMatrix) RankRR() int {
 	r := 0
 	for i := 0; i < m.numRows; i++ {
-		if m.Rows[i].Bits == 0 {
+		if m.Rows[i].IsZero() {
 			return r
 		}
 		r++
@@ -69,100 +56,136 @@ func (m *BitMatrix) RankRR() int {
 	return r
 }
 
-func (m *BitMatrix) rowReduceBelow() {
-	topRow := 0
-	leftColumn := 0
-
-	for topRow < m.numRows && leftColumn < m.numCols {
-		if topRow < m.numRows-1 {
-			pivotRow := topRow
-			pivotSuccessful := false
-			for !pivotSuccessful && pivotRow < m.numRows {
-				val, _ := m.Rows[pivotRow].Get(leftColumn)
-				if val != 0 {
-					if topRow != pivotRow {
-						m.Rows[topRow], m.Rows[pivotRow] = m.Rows[pivotRow], m.Rows[topRow]
-					}
-					pivotSuccessful = true
-				} else {
-					pivotRow++
+// reducedRowEchelon reduces the matrix to reduced row echelon form in-place and returns
+// the pivot column of each nonzero row. Row swaps are applied to perm if it is non-nil.
+func (m *BitMatrix) reducedRowEchelon(perm []int) []int {
+	pivots := m.rowReduceBelow(perm, nil)
+	pool := m.newRowPool()
+	defer pool.close()
+	// Clearing from the bottom pivot up means each row added in is already zero at
+	// every later pivot column.
+	for i := len(pivots) - 1; i >= 0; i-- {
+		col := pivots[i]
+		pool.run(0, i, func(lo, hi int) {
+			for row := lo; row < hi; row++ {
+				if bitAt(m.Rows[row], col) != 0 {
+					xorFromCol(m.Rows[row], m.Rows[i], col)
 				}
 			}
-			if !pivotSuccessful {
-				leftColumn++
-				continue
+		})
+	}
+	return pivots
+}
+
+// rowReduceBelow brings the matrix to row echelon form in-place, clearing only below
+// each pivot, and returns the pivot column of each nonzero row.
+//
+// If perm is non-nil, row swaps are applied to it. If l is non-nil it must be a zero
+// numRows x numRows matrix; on return it holds the unit lower-triangular L with
+// P A = L E, where P is the row permutation and E is the reduced matrix.
+func (m *BitMatrix) rowReduceBelow(perm []int, l *BitMatrix) []int {
+	var pivots []int
+	topRow := 0
+	pool := m.newRowPool()
+	defer pool.close()
+
+	for leftColumn := 0; topRow < m.numRows && leftColumn < m.numCols; leftColumn++ {
+		pivotRow := topRow
+		for pivotRow < m.numRows && bitAt(m.Rows[pivotRow], leftColumn) == 0 {
+			pivotRow++
+		}
+		if pivotRow == m.numRows {
+			continue
+		}
+		if pivotRow != topRow {
+			m.Rows[topRow], m.Rows[pivotRow] = m.Rows[pivotRow], m.Rows[topRow]
+			if perm != nil {
+				perm[topRow], perm[pivotRow] = perm[pivotRow], perm[topRow]
+			}
+			if l != nil {
+				// Only the multipliers left of the diagonal are filled in so far.
+				l.Rows[topRow], l.Rows[pivotRow] = l.Rows[pivotRow], l.Rows[topRow]
 			}
 		}
 
-		val, _ := m.Rows[topRow].Get(leftColumn)
-		if val != 0 {
-			for row := topRow + 1; row < m.numRows; row++ {
-				v, _ := m.Rows[row].Get(leftColumn)
-				if v != 0 {
-					m.Rows[row].Bits ^= m.Rows[topRow].Bits
+		// Rows below the pivot are independent of each other, so the pool may split them.
+		pool.run(topRow+1, m.numRows, func(lo, hi int) {
+			for row := lo; row < hi; row++ {
+				if bitAt(m.Rows[row], leftColumn) != 0 {
+					xorFromCol(m.Rows[row], m.Rows[topRow], leftColumn)
+					if l != nil {
+						l.Rows[row].Set(topRow, 1)
+					}
 				}
 			}
-		}
-		leftColumn++
+		})
+		pivots = append(pivots, leftColumn)
 		topRow++
 	}
+
+	if l != nil {
+		for i := 0; i < m.numRows; i++ {
+			l.Rows[i].Set(i, 1)
+		}
+	}
+	return pivots
 }
 
 func (m *BitMatrix) clone() *BitMatrix {
-	other, _ := New(m.numRows, m.numCols)
+	rows := make([]*bitvector.BitVector, m.numRows)
 	for i := 0; i < m.numRows; i++ {
-		other.Rows[i].Bits = m.Rows[i].Bits
+		rows[i] = m.Rows[i].Clone()
+	}
+	return &BitMatrix{numRows: m.numRows, numCols: m.numCols, Rows: rows}
+}
+
+// bitAt returns bit col of row without bounds checking; col is assumed < numCols.
+func bitAt(row *bitvector.BitVector, col int) uint64 {
+	return (row.Words[col>>6] >> (col & 63)) & 1
+}
+
+// xorFromCol sets dst ^= src, skipping the words below the one holding col. During
+// elimination src is zero to the left of its pivot column, so the skipped words
+// contribute nothing; on wide matrices this roughly halves the work.
+func xorFromCol(dst, src *bitvector.BitVector, col int) {
+	for k := col >> 6; k < len(dst.Words); k++ {
+		dst.Words[k] ^= src.Words[k]
 	}
-	return other
 }
 
 // KernelBasis returns a basis for the nullspace, or nil if nullity is zero.
 func (m *BitMatrix) KernelBasis() (*BitMatrix, error) {
 	rr := m.clone()
-	rr.RowEchelonForm()
-	rank := rr.RankRR()
+	pivots := rr.reducedRowEchelon(nil)
+	rank := len(pivots)
 	dimker := rr.numCols - rank
 	if dimker == 0 {
 		return nil, nil
 	}
 
 	basis, _ := New(dimker, rr.numCols)
-	freeFlags := make([]int, m.numCols)
-	for i := range freeFlags {
-		freeFlags[i] = 1
+	isPivot := make([]bool, m.numCols)
+	for _, col := range pivots {
+		isPivot[col] = true
 	}
-	freeIndices := make([]int, m.numCols)
-	nfree := 0
 
-	for i := 0; i < rank; i++ {
-		depPos := rr.Rows[i].FindLeaderPos()
-		if depPos >= 0 {
-			freeFlags[depPos] = 0
-		}
-	}
-	for i := 0; i < m.numCols; i++ {
-		if freeFlags[i] != 0 {
-			freeIndices[nfree] = i
-			nfree++
+	i := 0
+	for free := 0; free < m.numCols; free++ {
+		if isPivot[free] {
+			continue
 		}
-	}
-	if nfree != dimker {
-		return nil, fmt.Errorf("coding error detected: kernel_basis")
-	}
-
-	for i := 0; i < dimker; i++ {
-		basis.Rows[i].Set(freeIndices[i], 1)
+		// Setting this free variable to 1 and the others to 0 forces each pivot
+		// variable to the entry of its row in this column.
+		basis.Rows[i].Set(free, 1)
 		for j := 0; j < rank; j++ {
-			v, _ := rr.Rows[j].Get(freeIndices[i])
-			if v == 0 {
-				continue
-			}
-			depPos := rr.Rows[j].FindLeaderPos()
-			if depPos < 0 {
-				return nil, fmt.Errorf("coding error detected: kernel_basis")
+			if bitAt(rr.Rows[j], free) != 0 {
+				basis.Rows[i].Set(pivots[j], 1)
 			}
-			basis.Rows[i].Set(depPos, v)
 		}
+		i++
+	}
+	if i != dimker {
+		return nil, fmt.Errorf("coding error detected: kernel_basis")
 	}
 	return basis, nil
 }
@@ -0,0 +1,102 @@
+package bitmatrix
+
+import "testing"
+
+// randomMatrix fills an m x n matrix from a fixed LCG so tests are reproducible.
+func randomMatrix(t *testing.T, numRows, numCols int, seed uint64) *BitMatrix {
+	t.Helper()
+	a, err := New(numRows, numCols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := seed
+	for i := 0; i < numRows; i++ {
+		for j := 0; j < numCols; j++ {
+			x = x*6364136223846793005 + 1442695040888963407
+			a.Rows[i].Set(j, int(x>>63))
+		}
+	}
+	return a
+}
+
+func checkKernel(t *testing.T, a *BitMatrix) {
+	t.Helper()
+	rank := a.Rank()
+	basis, err := a.KernelBasis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nullity := 0
+	if basis != nil {
+		nullity = basis.NumRows()
+		if got := basis.Rank(); got != nullity {
+			t.Errorf("kernel basis rank = %d, want %d", got, nullity)
+		}
+		for k := 0; k < nullity; k++ {
+			for i := 0; i < a.NumRows(); i++ {
+				if d, _ := a.Rows[i].Dot(basis.Rows[k]); d != 0 {
+					t.Fatalf("row %d . kernel vector %d = 1", i, k)
+				}
+			}
+		}
+	}
+	if rank+nullity != a.NumCols() {
+		t.Errorf("rank %d + nullity %d != %d columns", rank, nullity, a.NumCols())
+	}
+}
+
+func TestRankIdentityWide(t *testing.T) {
+	for _, n := range []int{1, 63, 64, 65, 200} {
+		a, _ := New(n, n)
+		for i := 0; i < n; i++ {
+			a.Rows[i].Set(i, 1)
+		}
+		if got := a.Rank(); got != n {
+			t.Errorf("Rank(I_%d) = %d", n, got)
+		}
+		if basis, _ := a.KernelBasis(); basis != nil {
+			t.Errorf("KernelBasis(I_%d) should be nil", n)
+		}
+	}
+}
+
+func TestHighColumnsNotLost(t *testing.T) {
+	// Two rows that agree below column 64 and differ only above it.
+	a, _ := New(2, 130)
+	a.Rows[0].Set(0, 1)
+	a.Rows[1].Set(0, 1)
+	a.Rows[1].Set(129, 1)
+	if got := a.Rank(); got != 2 {
+		t.Errorf("Rank = %d, want 2", got)
+	}
+	checkKernel(t, a)
+}
+
+func TestKernelWide(t *testing.T) {
+	dims := [][2]int{{5, 8}, {64, 64}, {70, 150}, {150, 70}, {100, 257}}
+	for k, d := range dims {
+		checkKernel(t, randomMatrix(t, d[0], d[1], uint64(k+1)))
+	}
+}
+
+func TestRowEchelonFormWide(t *testing.T) {
+	a := randomMatrix(t, 90, 180, 7)
+	rank := a.Rank()
+	a.RowEchelonForm()
+	if got := a.RankRR(); got != rank {
+		t.Errorf("RankRR after RowEchelonForm = %d, want %d", got, rank)
+	}
+	prev := -1
+	for i := 0; i < rank; i++ {
+		lead := a.Rows[i].FindLeaderPos()
+		if lead <= prev {
+			t.Fatalf("row %d leader %d not right of previous %d", i, lead, prev)
+		}
+		for i2 := 0; i2 < rank; i2++ {
+			if v, _ := a.Rows[i2].Get(lead); i2 != i && v != 0 {
+				t.Fatalf("pivot column %d not cleared in row %d", lead, i2)
+			}
+		}
+		prev = lead
+	}
+}
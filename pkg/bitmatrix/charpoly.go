@@ -0,0 +1,185 @@
+package bitmatrix
+
+import (
+	"fmt"
+
+	"github.com/johnkerl/goffl/pkg/bitvector"
+	"github.com/johnkerl/goffl/pkg/f2poly"
+)
+
+// maxPolyDim is the largest n for which an n x n matrix's characteristic polynomial,
+// of degree n, fits in an F2Poly.
+const maxPolyDim = 63
+
+// CompanionMatrix returns the companion matrix of f, whose characteristic and minimal
+// polynomials are both f. Column j is C e_j: e_{j+1} for j < n-1, and the low
+// coefficients of f for j = n-1, so C acts on column vectors via MulVec.
+func CompanionMatrix(f *f2poly.F2Poly) (*BitMatrix, error) {
+	n := f.Degree()
+	if n < 1 {
+		return nil, fmt.Errorf("CompanionMatrix: degree must be positive; got %d", n)
+	}
+	c, _ := New(n, n)
+	for j := 0; j < n-1; j++ {
+		c.Rows[j+1].Set(j, 1)
+	}
+	for i := 0; i < n; i++ {
+		c.Rows[i].Set(n-1, f.Get(i))
+	}
+	return c, nil
+}
+
+// Pow returns m^e for square m. Negative exponents use the inverse.
+func (m *BitMatrix) Pow(e int) (*BitMatrix, error) {
+	if m.numRows != m.numCols {
+		return nil, fmt.Errorf("BitMatrix pow: matrix is %d x %d, not square", m.numRows, m.numCols)
+	}
+	xp := m
+	// The magnitude is taken as a uint, where -e is still right for e = math.MinInt.
+	u := uint(e)
+	if e < 0 {
+		inv, err := m.Inverse()
+		if err != nil {
+			return nil, err
+		}
+		xp = inv
+		u = -u
+	}
+	rv, _ := Identity(m.numRows)
+	for u != 0 {
+		if u&1 == 1 {
+			rv, _ = rv.Mul(xp)
+		}
+		u >>= 1
+		if u != 0 {
+			xp, _ = xp.Mul(xp)
+		}
+	}
+	return rv, nil
+}
+
+// EvalPoly returns f(m) for square m.
+func (m *BitMatrix) EvalPoly(f *f2poly.F2Poly) (*BitMatrix, error) {
+	if m.numRows != m.numCols {
+		return nil, fmt.Errorf("BitMatrix evalpoly: matrix is %d x %d, not square", m.numRows, m.numCols)
+	}
+	rv, _ := New(m.numRows, m.numCols)
+	for k := f.Degree(); k >= 0; k-- {
+		rv, _ = rv.Mul(m)
+		if f.Get(k) == 1 {
+			for i := 0; i < m.numRows; i++ {
+				rv.Rows[i].ToggleElement(i)
+			}
+		}
+	}
+	return rv, nil
+}
+
+// CharPoly returns the characteristic polynomial det(xI - m) for square m of size at
+// most 63.
+//
+// The space is built up as a chain of invariant subspaces W_0 = 0 < W_1 < ... by
+// adjoining the Krylov sequence v, m v, m^2 v, ... of a vector v outside W_k until
+// q(m) v lands in W_k + span(v, ..., m^{d-1} v). Then m acts cyclically on W_{k+1}/W_k
+// with characteristic polynomial q, and the characteristic polynomial of m is the
+// product of the q's.
+func (m *BitMatrix) CharPoly() (*f2poly.F2Poly, error) {
+	if err := m.checkPolyDims("charpoly"); err != nil {
+		return nil, err
+	}
+	n := m.numRows
+	w := newKrylovBasis()
+	rv := f2poly.New(1)
+	for j := 0; j < n && w.size() < n; j++ {
+		e, _ := bitvector.New(n)
+		e.Set(j, 1)
+		if q := w.adjoinKrylov(m, e); q != nil {
+			rv = rv.Mul(q)
+		}
+	}
+	return rv, nil
+}
+
+// MinPoly returns the minimal polynomial of square m of size at most 63: the least
+// common multiple of the minimal polynomials of the Krylov sequences of the standard
+// basis vectors.
+func (m *BitMatrix) MinPoly() (*f2poly.F2Poly, error) {
+	if err := m.checkPolyDims("minpoly"); err != nil {
+		return nil, err
+	}
+	n := m.numRows
+	rv := f2poly.New(1)
+	for j := 0; j < n; j++ {
+		e, _ := bitvector.New(n)
+		e.Set(j, 1)
+		q := newKrylovBasis().adjoinKrylov(m, e)
+		// Divide before multiplying so the intermediate stays within degree n.
+		rv = rv.Quo(rv.Gcd(q)).Mul(q)
+	}
+	return rv, nil
+}
+
+func (m *BitMatrix) checkPolyDims(op string) error {
+	if m.numRows != m.numCols {
+		return fmt.Errorf("BitMatrix %s: matrix is %d x %d, not square", op, m.numRows, m.numCols)
+	}
+	if m.numRows > maxPolyDim {
+		return fmt.Errorf("BitMatrix %s: dimension %d exceeds %d", op, m.numRows, maxPolyDim)
+	}
+	return nil
+}
+
+// krylovBasis is an echelonized basis of a subspace, each vector tagged with the
+// polynomial p such that the vector is p(m) v modulo the subspace as it was before the
+// current Krylov sequence began.
+type krylovBasis struct {
+	vecs  []*bitvector.BitVector
+	tags  []uint64
+	leads []int
+}
+
+func newKrylovBasis() *krylovBasis {
+	return &krylovBasis{}
+}
+
+func (w *krylovBasis) size() int { return len(w.vecs) }
+
+// adjoinKrylov extends w by the Krylov sequence of v and returns the polynomial q, of
+// degree d, with q(m) v in the old w plus span(v, ..., m^{d-1} v). Returns nil if v is
+// already in w.
+func (w *krylovBasis) adjoinKrylov(m *BitMatrix, v *bitvector.BitVector) *f2poly.F2Poly {
+	// Vectors already in w were formed before this sequence, so their tags are zero
+	// for the purposes of this call.
+	for i := range w.tags {
+		w.tags[i] = 0
+	}
+	cur := v
+	for d := 0; ; d++ {
+		red := cur.Clone()
+		tag := uint64(1) << d
+		for i, vec := range w.vecs {
+			if bitAt(red, w.leads[i]) != 0 {
+				red.XorInPlace(vec)
+				tag ^= w.tags[i]
+			}
+		}
+		lead := red.FindLeaderPos()
+		if lead < 0 {
+			if d == 0 {
+				return nil
+			}
+			return f2poly.New(tag)
+		}
+		// Keep the basis fully reduced at each leading position.
+		for i, vec := range w.vecs {
+			if bitAt(vec, lead) != 0 {
+				vec.XorInPlace(red)
+				w.tags[i] ^= tag
+			}
+		}
+		w.vecs = append(w.vecs, red)
+		w.tags = append(w.tags, tag)
+		w.leads = append(w.leads, lead)
+		cur, _ = m.MulVec(cur)
+	}
+}
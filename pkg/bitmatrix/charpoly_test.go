@@ -0,0 +1,132 @@
+package bitmatrix
+
+import (
+	"math"
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/f2poly"
+)
+
+func isZeroMatrix(m *BitMatrix) bool {
+	for i := 0; i < m.NumRows(); i++ {
+		if !m.Rows[i].IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCompanionMatrix(t *testing.T) {
+	for _, bits := range []uint64{0x3, 0x7, 0x13, 0x11b, 0x1f, 1<<62 | 1, 0xdeadbeef} {
+		f := f2poly.New(bits)
+		c, err := CompanionMatrix(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cp, err := c.CharPoly()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cp.Equal(f) {
+			t.Errorf("CharPoly(C(%x)) = %x", bits, cp.Bits)
+		}
+		mp, _ := c.MinPoly()
+		if !mp.Equal(f) {
+			t.Errorf("MinPoly(C(%x)) = %x", bits, mp.Bits)
+		}
+	}
+	if _, err := CompanionMatrix(f2poly.New(1)); err == nil {
+		t.Error("CompanionMatrix of a constant should fail")
+	}
+}
+
+func TestCharPolyIdentity(t *testing.T) {
+	id, _ := Identity(5)
+	cp, _ := id.CharPoly()
+	want, _ := f2poly.New(3).Pow(5)
+	if !cp.Equal(want) {
+		t.Errorf("CharPoly(I_5) = %x, want %x", cp.Bits, want.Bits)
+	}
+	mp, _ := id.MinPoly()
+	if mp.Bits != 3 {
+		t.Errorf("MinPoly(I_5) = %x, want 3", mp.Bits)
+	}
+	z, _ := New(4, 4)
+	if cp, _ := z.CharPoly(); cp.Bits != 0x10 {
+		t.Errorf("CharPoly(0_4) = %x, want 10", cp.Bits)
+	}
+}
+
+func TestCayleyHamilton(t *testing.T) {
+	for _, n := range []int{1, 2, 7, 32, 63} {
+		for seed := uint64(1); seed <= 3; seed++ {
+			a := randomMatrix(t, n, n, seed+uint64(n))
+			cp, err := a.CharPoly()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cp.Degree() != n {
+				t.Errorf("n=%d: charpoly degree %d", n, cp.Degree())
+			}
+			if ev, _ := a.EvalPoly(cp); !isZeroMatrix(ev) {
+				t.Errorf("n=%d: CharPoly(A)(A) != 0", n)
+			}
+			mp, _ := a.MinPoly()
+			if ev, _ := a.EvalPoly(mp); !isZeroMatrix(ev) {
+				t.Errorf("n=%d: MinPoly(A)(A) != 0", n)
+			}
+			if !cp.Mod(mp).IsZero() {
+				t.Errorf("n=%d: minpoly does not divide charpoly", n)
+			}
+			// det(A) is the constant term of the characteristic polynomial.
+			if det, _ := a.Det(); det != cp.Get(0) {
+				t.Errorf("n=%d: det %d, charpoly constant %d", n, det, cp.Get(0))
+			}
+		}
+	}
+
+	big, _ := New(64, 64)
+	if _, err := big.CharPoly(); err == nil {
+		t.Error("CharPoly of 64 x 64 should fail")
+	}
+	r, _ := New(2, 3)
+	if _, err := r.MinPoly(); err == nil {
+		t.Error("MinPoly of non-square should fail")
+	}
+}
+
+func TestPow(t *testing.T) {
+	a := randomMatrix(t, 20, 20, 9)
+	want, _ := Identity(20)
+	for e := 0; e <= 9; e++ {
+		got, err := a.Pow(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(want) {
+			t.Errorf("A^%d mismatch", e)
+		}
+		want, _ = want.Mul(a)
+	}
+
+	// x has order 15 in F2[x]/(x^4+x+1), so the companion matrix does too.
+	c, _ := CompanionMatrix(f2poly.New(0x13))
+	id, _ := Identity(4)
+	if p, _ := c.Pow(15); !p.Equal(id) {
+		t.Error("C^15 != I")
+	}
+	if p, _ := c.Pow(5); p.Equal(id) {
+		t.Error("C^5 == I")
+	}
+	inv, _ := c.Pow(-1)
+	if p, _ := inv.Mul(c); !p.Equal(id) {
+		t.Error("C^-1 C != I")
+	}
+	// 2^63 and 2^31 are 8 mod 15, so both extremes come to C^7.
+	c7, _ := c.Pow(7)
+	for _, e := range []int{math.MaxInt, math.MinInt} {
+		if p, err := c.Pow(e); err != nil || !p.Equal(c7) {
+			t.Errorf("C^%d = %v, %v; want C^7", e, p, err)
+		}
+	}
+}
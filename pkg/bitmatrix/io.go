@@ -0,0 +1,322 @@
+package bitmatrix
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/johnkerl/goffl/pkg/bitvector"
+)
+
+// Import and export. All formats write rows top to bottom and, like BitVector.String(),
+// put column 0 last (rightmost), so the text dump and the image of a matrix look alike.
+// None of them depend on SetHexOutput/SetBinaryOutput.
+//
+//   - Text: one row per line of '0'/'1' characters.
+//   - Hex: a "numRows numCols" line, then one row per line of hex digits.
+//   - JSON: {"numRows": r, "numCols": c, "rows": ["hex", ...]}.
+//   - PBM: netpbm bitmaps, plain (P1) or raw (P4), with 1 as a black pixel.
+//
+// The readers skip blank lines and lines starting with '#'.
+
+func (m *BitMatrix) String() string {
+	var sb strings.Builder
+	for i, row := range m.Rows {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(row.String())
+	}
+	return sb.String()
+}
+
+// WriteText writes the matrix as lines of '0'/'1' characters.
+func (m *BitMatrix) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, row := range m.Rows {
+		bw.WriteString(row.BinaryString())
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReadText reads a matrix written by WriteText. All rows must have the same length.
+func ReadText(r io.Reader) (*BitMatrix, error) {
+	lines, err := dataLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("BitMatrix read: no rows")
+	}
+	rows := make([]*bitvector.BitVector, len(lines))
+	for i, line := range lines {
+		if len(line) != len(lines[0]) {
+			return nil, fmt.Errorf("BitMatrix read: row %d has %d columns, want %d", i, len(line), len(lines[0]))
+		}
+		rows[i], err = bitvector.ParseBinary(line)
+		if err != nil {
+			return nil, fmt.Errorf("BitMatrix read: row %d: %w", i, err)
+		}
+	}
+	return &BitMatrix{numRows: len(rows), numCols: len(lines[0]), Rows: rows}, nil
+}
+
+// WriteHex writes a "numRows numCols" header, then the rows as hex digits.
+func (m *BitMatrix) WriteHex(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d %d\n", m.numRows, m.numCols)
+	for _, row := range m.Rows {
+		bw.WriteString(row.HexString())
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReadHex reads a matrix written by WriteHex.
+func ReadHex(r io.Reader) (*BitMatrix, error) {
+	lines, err := dataLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("BitMatrix read: missing header")
+	}
+	var numRows, numCols int
+	if _, err := fmt.Sscanf(lines[0], "%d %d", &numRows, &numCols); err != nil {
+		return nil, fmt.Errorf("BitMatrix read: bad header %q: %w", lines[0], err)
+	}
+	return fromHexRows(numRows, numCols, lines[1:])
+}
+
+// fromHexRows checks the header dimensions against the rows before allocating anything,
+// so that a few bytes of input cannot ask for a huge matrix.
+func fromHexRows(numRows, numCols int, lines []string) (*BitMatrix, error) {
+	if numRows <= 0 || numCols <= 0 {
+		return nil, fmt.Errorf("BitMatrix read: dimensions must be > 0; got %d x %d", numRows, numCols)
+	}
+	if len(lines) != numRows {
+		return nil, fmt.Errorf("BitMatrix read: got %d rows, want %d", len(lines), numRows)
+	}
+	width := (numCols + 3) / 4
+	rows := make([]*bitvector.BitVector, numRows)
+	for i, line := range lines {
+		digits := strings.TrimPrefix(strings.TrimPrefix(line, "0x"), "0X")
+		if len(digits) < width {
+			return nil, fmt.Errorf("BitMatrix read: row %d has %d hex digits, want %d", i, len(digits), width)
+		}
+		var err error
+		rows[i], err = bitvector.ParseHex(line, numCols)
+		if err != nil {
+			return nil, fmt.Errorf("BitMatrix read: row %d: %w", i, err)
+		}
+	}
+	return &BitMatrix{numRows: numRows, numCols: numCols, Rows: rows}, nil
+}
+
+type matrixJSON struct {
+	NumRows int      `json:"numRows"`
+	NumCols int      `json:"numCols"`
+	Rows    []string `json:"rows"`
+}
+
+// MarshalJSON writes the matrix as {"numRows": r, "numCols": c, "rows": ["hex", ...]}.
+func (m *BitMatrix) MarshalJSON() ([]byte, error) {
+	mj := matrixJSON{NumRows: m.numRows, NumCols: m.numCols, Rows: make([]string, m.numRows)}
+	for i, row := range m.Rows {
+		mj.Rows[i] = row.HexString()
+	}
+	return json.Marshal(mj)
+}
+
+func (m *BitMatrix) UnmarshalJSON(data []byte) error {
+	var mj matrixJSON
+	if err := json.Unmarshal(data, &mj); err != nil {
+		return err
+	}
+	parsed, err := fromHexRows(mj.NumRows, mj.NumCols, mj.Rows)
+	if err != nil {
+		return err
+	}
+	*m = *parsed
+	return nil
+}
+
+// WritePBMPlain writes the matrix as a plain (P1) PBM image, one pixel per entry.
+func (m *BitMatrix) WritePBMPlain(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P1\n%d %d\n", m.numCols, m.numRows)
+	for _, row := range m.Rows {
+		// PBM lines should stay within 70 characters.
+		s := row.BinaryString()
+		for len(s) > 70 {
+			bw.WriteString(s[:70])
+			bw.WriteByte('\n')
+			s = s[70:]
+		}
+		bw.WriteString(s)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// WritePBMRaw writes the matrix as a raw (P4) PBM image, eight pixels per byte.
+func (m *BitMatrix) WritePBMRaw(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P4\n%d %d\n", m.numCols, m.numRows)
+	rowBytes := make([]byte, (m.numCols+7)/8)
+	for _, row := range m.Rows {
+		clear(rowBytes)
+		for x := 0; x < m.numCols; x++ {
+			if bitAt(row, m.numCols-1-x) != 0 {
+				rowBytes[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		bw.Write(rowBytes)
+	}
+	return bw.Flush()
+}
+
+// ReadPBM reads a plain (P1) or raw (P4) PBM image.
+func ReadPBM(r io.Reader) (*BitMatrix, error) {
+	br := bufio.NewReader(r)
+	magic, err := pbmToken(br)
+	if err != nil {
+		return nil, err
+	}
+	if magic != "P1" && magic != "P4" {
+		return nil, fmt.Errorf("BitMatrix read: not a PBM image (magic %q)", magic)
+	}
+	var dims [2]int
+	for k := range dims {
+		tok, err := pbmToken(br)
+		if err != nil {
+			return nil, err
+		}
+		dims[k], err = strconv.Atoi(tok)
+		if err != nil {
+			return nil, fmt.Errorf("BitMatrix read: bad PBM dimension %q", tok)
+		}
+	}
+	numCols, numRows := dims[0], dims[1]
+	if numRows <= 0 || numCols <= 0 || numCols > math.MaxInt/numRows {
+		return nil, fmt.Errorf("BitMatrix read: bad PBM dimensions %d x %d", numCols, numRows)
+	}
+
+	// Read the whole raster before allocating the matrix, so that a short input cannot
+	// ask for a huge one: what is buffered grows only as fast as the input does.
+	if magic == "P1" {
+		var pixels []byte
+		for len(pixels) < numRows*numCols {
+			c, err := pbmPixel(br)
+			if err != nil {
+				return nil, err
+			}
+			pixels = append(pixels, c)
+		}
+		m, err := New(numRows, numCols)
+		if err != nil {
+			return nil, err
+		}
+		for k, c := range pixels {
+			if c == '1' {
+				m.Rows[k/numCols].Set(numCols-1-k%numCols, 1)
+			}
+		}
+		return m, nil
+	}
+
+	// The single whitespace byte after the height was consumed by pbmToken.
+	rowBytes := (numCols + 7) / 8
+	raster, err := io.ReadAll(io.LimitReader(br, int64(numRows*rowBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("BitMatrix read: PBM raster: %w", err)
+	}
+	if len(raster) < numRows*rowBytes {
+		return nil, fmt.Errorf("BitMatrix read: PBM raster has %d bytes, want %d", len(raster), numRows*rowBytes)
+	}
+	m, err := New(numRows, numCols)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < numRows; i++ {
+		row := raster[i*rowBytes : (i+1)*rowBytes]
+		for x := 0; x < numCols; x++ {
+			if row[x/8]&(0x80>>(x%8)) != 0 {
+				m.Rows[i].Set(numCols-1-x, 1)
+			}
+		}
+	}
+	return m, nil
+}
+
+// pbmToken returns the next whitespace-delimited header token, skipping '#' comments.
+// It consumes exactly one whitespace byte after the token.
+func pbmToken(br *bufio.Reader) (string, error) {
+	var sb strings.Builder
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			if err == io.EOF && sb.Len() > 0 {
+				return sb.String(), nil
+			}
+			return "", fmt.Errorf("BitMatrix read: PBM header: %w", err)
+		}
+		switch {
+		case c == '#' && sb.Len() == 0:
+			if _, err := br.ReadString('\n'); err != nil {
+				return "", fmt.Errorf("BitMatrix read: PBM header: %w", err)
+			}
+		case isPBMSpace(c):
+			if sb.Len() > 0 {
+				return sb.String(), nil
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// pbmPixel returns the next '0' or '1' of a plain PBM raster.
+func pbmPixel(br *bufio.Reader) (byte, error) {
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("BitMatrix read: PBM raster: %w", err)
+		}
+		switch {
+		case c == '0' || c == '1':
+			return c, nil
+		case c == '#':
+			br.ReadString('\n')
+		case !isPBMSpace(c):
+			return 0, fmt.Errorf("BitMatrix read: invalid PBM pixel %q", c)
+		}
+	}
+}
+
+func isPBMSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// dataLines returns the trimmed lines of r, skipping blank lines and '#' comments.
+func dataLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
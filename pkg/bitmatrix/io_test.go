@@ -0,0 +1,154 @@
+package bitmatrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var ioDims = [][2]int{{1, 1}, {3, 7}, {8, 8}, {5, 70}, {70, 130}}
+
+func TestTextRoundTrip(t *testing.T) {
+	for k, d := range ioDims {
+		a := randomMatrix(t, d[0], d[1], uint64(k+41))
+		var buf bytes.Buffer
+		if err := a.WriteText(&buf); err != nil {
+			t.Fatal(err)
+		}
+		b, err := ReadText(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !b.Equal(a) {
+			t.Errorf("%dx%d: text round trip failed", d[0], d[1])
+		}
+	}
+	b, err := ReadText(strings.NewReader("# comment\n011\n\n100\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := b.Rows[0].Get(0); v != 1 || b.NumRows() != 2 || b.NumCols() != 3 {
+		t.Error("ReadText did not read column 0 from the right")
+	}
+	if _, err := ReadText(strings.NewReader("01\n011\n")); err == nil {
+		t.Error("ReadText of ragged rows should fail")
+	}
+	if _, err := ReadText(strings.NewReader("012\n")); err == nil {
+		t.Error("ReadText of a non-binary digit should fail")
+	}
+}
+
+func TestHexRoundTrip(t *testing.T) {
+	for k, d := range ioDims {
+		a := randomMatrix(t, d[0], d[1], uint64(k+51))
+		var buf bytes.Buffer
+		if err := a.WriteHex(&buf); err != nil {
+			t.Fatal(err)
+		}
+		b, err := ReadHex(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !b.Equal(a) {
+			t.Errorf("%dx%d: hex round trip failed", d[0], d[1])
+		}
+	}
+	if _, err := ReadHex(strings.NewReader("2 3\n7\n8\n")); err == nil {
+		t.Error("ReadHex with a row too wide should fail")
+	}
+	if _, err := ReadHex(strings.NewReader("2 3\n7\n")); err == nil {
+		t.Error("ReadHex with missing rows should fail")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for k, d := range ioDims {
+		a := randomMatrix(t, d[0], d[1], uint64(k+61))
+		data, err := json.Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b BitMatrix
+		if err := json.Unmarshal(data, &b); err != nil {
+			t.Fatal(err)
+		}
+		if !b.Equal(a) {
+			t.Errorf("%dx%d: JSON round trip failed", d[0], d[1])
+		}
+		// Vectors round trip on their own as well.
+		row := a.Rows[0]
+		vdata, _ := json.Marshal(row)
+		back := row.Not()
+		if err := json.Unmarshal(vdata, back); err != nil {
+			t.Fatal(err)
+		}
+		if !back.Equal(row) {
+			t.Errorf("%dx%d: vector JSON round trip failed", d[0], d[1])
+		}
+	}
+	var b BitMatrix
+	if err := json.Unmarshal([]byte(`{"numRows":1,"numCols":2,"rows":["7"]}`), &b); err == nil {
+		t.Error("JSON with a row too wide should fail")
+	}
+}
+
+func TestPBMRoundTrip(t *testing.T) {
+	for k, d := range ioDims {
+		a := randomMatrix(t, d[0], d[1], uint64(k+71))
+		for _, write := range []func(*BitMatrix, *bytes.Buffer) error{
+			func(m *BitMatrix, b *bytes.Buffer) error { return m.WritePBMPlain(b) },
+			func(m *BitMatrix, b *bytes.Buffer) error { return m.WritePBMRaw(b) },
+		} {
+			var buf bytes.Buffer
+			if err := write(a, &buf); err != nil {
+				t.Fatal(err)
+			}
+			b, err := ReadPBM(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !b.Equal(a) {
+				t.Errorf("%dx%d: PBM round trip failed", d[0], d[1])
+			}
+		}
+	}
+
+	b, err := ReadPBM(strings.NewReader("P1\n# a comment\n3 2\n1 0 0\n0 1 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "100\n011" {
+		t.Errorf("ReadPBM = %q", got)
+	}
+	if _, err := ReadPBM(strings.NewReader("P2\n1 1\n0\n")); err == nil {
+		t.Error("ReadPBM of a graymap should fail")
+	}
+	if _, err := ReadPBM(strings.NewReader("P4\n9 2\n\xff")); err == nil {
+		t.Error("ReadPBM of a truncated raster should fail")
+	}
+}
+
+// A huge header over a tiny body must be rejected before anything is allocated
+// for it; otherwise each of these would ask for about a petabyte.
+func TestReadHugeHeader(t *testing.T) {
+	const huge = "100000000 100000000"
+	if _, err := ReadHex(strings.NewReader(huge + "\n0\n")); err == nil {
+		t.Error("ReadHex with a huge header should fail")
+	}
+	if _, err := ReadHex(strings.NewReader("1 100000000\n0\n")); err == nil {
+		t.Error("ReadHex with a short row should fail")
+	}
+	var m BitMatrix
+	if err := json.Unmarshal([]byte(`{"numRows":100000000,"numCols":100000000,"rows":["0"]}`), &m); err == nil {
+		t.Error("UnmarshalJSON with a huge header should fail")
+	}
+	if err := json.Unmarshal([]byte(`{"numRows":1,"numCols":100000000,"rows":["0"]}`), &m); err == nil {
+		t.Error("UnmarshalJSON with a short row should fail")
+	}
+	for _, in := range []string{"P1\n" + huge + "\n0 1\n", "P4\n" + huge + "\n\xff", "P4\n3037000500 3037000500\n"} {
+		if _, err := ReadPBM(strings.NewReader(in)); err == nil {
+			t.Errorf("ReadPBM(%q) should fail", in)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package bitmatrix
+
+import (
+	"fmt"
+
+	"github.com/johnkerl/goffl/pkg/bitarith"
+	"github.com/johnkerl/goffl/pkg/bitvector"
+)
+
+// m4rmBlockBits is the number of columns of the left factor handled per lookup table in
+// the Method of Four Russians. Eight keeps each block within one word and the table at
+// 256 rows.
+const m4rmBlockBits = 8
+
+// m4rmThreshold is the inner dimension at and above which Mul uses the Method of Four
+// Russians. Below it, building the tables costs more than it saves.
+const m4rmThreshold = 128
+
+// Identity returns the n x n identity matrix.
+func Identity(n int) (*BitMatrix, error) {
+	m, err := New(n, n)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		m.Rows[i].Set(i, 1)
+	}
+	return m, nil
+}
+
+// Random returns a numRows x numCols matrix with uniformly random entries.
+func Random(numRows, numCols int) (*BitMatrix, error) {
+	if numRows <= 0 || numCols <= 0 {
+		return nil, fmt.Errorf("BitMatrix: dimensions must be > 0; got %d x %d", numRows, numCols)
+	}
+	rows := make([]*bitvector.BitVector, numRows)
+	for i := range rows {
+		v, err := bitvector.Random(numCols)
+		if err != nil {
+			return nil, err
+		}
+		rows[i] = v
+	}
+	return &BitMatrix{numRows: numRows, numCols: numCols, Rows: rows}, nil
+}
+
+func (m *BitMatrix) Equal(other *BitMatrix) bool {
+	if m.numRows != other.numRows || m.numCols != other.numCols {
+		return false
+	}
+	for i := 0; i < m.numRows; i++ {
+		if !m.Rows[i].Equal(other.Rows[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *BitMatrix) Transpose() *BitMatrix {
+	t, _ := New(m.numCols, m.numRows)
+	for i := 0; i < m.numRows; i++ {
+		for j := range m.Rows[i].SetPositions() {
+			t.Rows[j].Words[i>>6] |= 1 << (i & 63)
+		}
+	}
+	return t
+}
+
+// Add returns m + other, i.e. the entrywise XOR.
+func (m *BitMatrix) Add(other *BitMatrix) (*BitMatrix, error) {
+	if m.numRows != other.numRows || m.numCols != other.numCols {
+		return nil, fmt.Errorf("BitMatrix add: dimension mismatch %d x %d vs %d x %d",
+			m.numRows, m.numCols, other.numRows, other.numCols)
+	}
+	rv := m.clone()
+	for i := 0; i < m.numRows; i++ {
+		rv.Rows[i].XorInPlace(other.Rows[i])
+	}
+	return rv, nil
+}
+
+// Mul returns the matrix product m * other over GF(2). Large products use the Method of
+// Four Russians (M4RM).
+func (m *BitMatrix) Mul(other *BitMatrix) (*BitMatrix, error) {
+	if m.numCols != other.numRows {
+		return nil, fmt.Errorf("BitMatrix mul: dimension mismatch %d x %d times %d x %d",
+			m.numRows, m.numCols, other.numRows, other.numCols)
+	}
+	if m.numCols >= m4rmThreshold {
+		return m.mulM4RM(other), nil
+	}
+	return m.mulRows(other), nil
+}
+
+// mulRows forms each row of the product as the XOR of the rows of other selected by the
+// set bits of the corresponding row of m.
+func (m *BitMatrix) mulRows(other *BitMatrix) *BitMatrix {
+	c, _ := New(m.numRows, other.numCols)
+	for i := 0; i < m.numRows; i++ {
+		for k := range m.Rows[i].SetPositions() {
+			c.Rows[i].XorInPlace(other.Rows[k])
+		}
+	}
+	return c
+}
+
+// mulM4RM is the Method of Four Russians. For each block of m4rmBlockBits columns of m,
+// it tabulates all XOR combinations of the matching rows of other, then adds one table
+// entry to each output row. This replaces up to m4rmBlockBits row additions per output
+// row with one.
+func (m *BitMatrix) mulM4RM(other *BitMatrix) *BitMatrix {
+	c, _ := New(m.numRows, other.numCols)
+	nw := len(c.Rows[0].Words)
+	table := make([]uint64, (1<<m4rmBlockBits)*nw)
+
+	for col := 0; col < m.numCols; col += m4rmBlockBits {
+		width := min(m4rmBlockBits, m.numCols-col)
+		size := 1 << width
+		// Entry idx is the sum of rows col+b of other for each set bit b of idx. Each
+		// entry is one row addition on top of an entry already computed.
+		for idx := 1; idx < size; idx++ {
+			low := idx & -idx
+			prev := table[(idx^low)*nw : (idx^low+1)*nw]
+			row := other.Rows[col+bitarith.LsbPos(uint64(low))].Words
+			dst := table[idx*nw : (idx+1)*nw]
+			for k := range dst {
+				dst[k] = prev[k] ^ row[k]
+			}
+		}
+		wordIndex, shift := col>>6, uint(col&63)
+		mask := uint64(size - 1)
+		for i := 0; i < m.numRows; i++ {
+			idx := int((m.Rows[i].Words[wordIndex] >> shift) & mask)
+			if idx == 0 {
+				continue
+			}
+			src := table[idx*nw : (idx+1)*nw]
+			dst := c.Rows[i].Words
+			for k := range dst {
+				dst[k] ^= src[k]
+			}
+		}
+	}
+	return c
+}
+
+// MulVec returns the matrix-vector product m * v, where v has NumCols() bits and the
+// result has NumRows() bits.
+func (m *BitMatrix) MulVec(v *bitvector.BitVector) (*bitvector.BitVector, error) {
+	if v.NumBits() != m.numCols {
+		return nil, fmt.Errorf("BitMatrix mulvec: vector length %d != %d columns", v.NumBits(), m.numCols)
+	}
+	rv, _ := bitvector.New(m.numRows)
+	for i := 0; i < m.numRows; i++ {
+		d, _ := m.Rows[i].Dot(v)
+		rv.Set(i, d)
+	}
+	return rv, nil
+}
+
+// VecMul returns the vector-matrix product v * m, where v has NumRows() bits and the
+// result has NumCols() bits.
+func (m *BitMatrix) VecMul(v *bitvector.BitVector) (*bitvector.BitVector, error) {
+	if v.NumBits() != m.numRows {
+		return nil, fmt.Errorf("BitMatrix vecmul: vector length %d != %d rows", v.NumBits(), m.numRows)
+	}
+	rv, _ := bitvector.New(m.numCols)
+	for i := range v.SetPositions() {
+		rv.XorInPlace(m.Rows[i])
+	}
+	return rv, nil
+}
@@ -0,0 +1,157 @@
+package bitmatrix
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/bitvector"
+)
+
+// mulNaive is the textbook triple loop, one bit at a time. It is the reference for
+// correctness tests and the baseline for benchmarks.
+func mulNaive(a, b *BitMatrix) *BitMatrix {
+	c, _ := New(a.NumRows(), b.NumCols())
+	for i := 0; i < a.NumRows(); i++ {
+		for j := 0; j < b.NumCols(); j++ {
+			sum := 0
+			for k := 0; k < a.NumCols(); k++ {
+				x, _ := a.Rows[i].Get(k)
+				y, _ := b.Rows[k].Get(j)
+				sum ^= x & y
+			}
+			c.Rows[i].Set(j, sum)
+		}
+	}
+	return c
+}
+
+func TestMulAgainstNaive(t *testing.T) {
+	dims := [][3]int{{1, 1, 1}, {3, 5, 7}, {17, 63, 9}, {40, 64, 70}, {65, 130, 66}, {100, 203, 129}}
+	for k, d := range dims {
+		a := randomMatrix(t, d[0], d[1], uint64(2*k+1))
+		b := randomMatrix(t, d[1], d[2], uint64(2*k+2))
+		want := mulNaive(a, b)
+		got, err := a.Mul(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(want) {
+			t.Errorf("Mul %dx%d * %dx%d disagrees with naive", d[0], d[1], d[1], d[2])
+		}
+		if !a.mulM4RM(b).Equal(want) || !a.mulRows(b).Equal(want) {
+			t.Errorf("mulM4RM/mulRows %dx%d * %dx%d disagree with naive", d[0], d[1], d[1], d[2])
+		}
+	}
+	a := randomMatrix(t, 3, 4, 1)
+	if _, err := a.Mul(a); err == nil {
+		t.Error("Mul with mismatched dimensions should fail")
+	}
+}
+
+func TestIdentityTransposeAdd(t *testing.T) {
+	a := randomMatrix(t, 70, 90, 3)
+	i70, _ := Identity(70)
+	i90, _ := Identity(90)
+	if got, _ := i70.Mul(a); !got.Equal(a) {
+		t.Error("I * A != A")
+	}
+	if got, _ := a.Mul(i90); !got.Equal(a) {
+		t.Error("A * I != A")
+	}
+	at := a.Transpose()
+	if at.NumRows() != 90 || at.NumCols() != 70 {
+		t.Fatalf("transpose dims %d x %d", at.NumRows(), at.NumCols())
+	}
+	if !at.Transpose().Equal(a) {
+		t.Error("transpose is not an involution")
+	}
+	b := randomMatrix(t, 90, 50, 4)
+	ab, _ := a.Mul(b)
+	btat, _ := b.Transpose().Mul(at)
+	if !ab.Transpose().Equal(btat) {
+		t.Error("(AB)^T != B^T A^T")
+	}
+	sum, err := a.Add(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < sum.NumRows(); i++ {
+		if !sum.Rows[i].IsZero() {
+			t.Fatal("A + A != 0")
+		}
+	}
+	if _, err := a.Add(b); err == nil {
+		t.Error("Add with mismatched dimensions should fail")
+	}
+}
+
+func TestMulVec(t *testing.T) {
+	a := randomMatrix(t, 70, 130, 5)
+	v, _ := bitvector.Random(130)
+	col, _ := New(130, 1)
+	for j := range v.SetPositions() {
+		col.Rows[j].Set(0, 1)
+	}
+	want, _ := a.Mul(col)
+	got, err := a.MulVec(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 70; i++ {
+		x, _ := got.Get(i)
+		y, _ := want.Rows[i].Get(0)
+		if x != y {
+			t.Fatalf("MulVec bit %d = %d, want %d", i, x, y)
+		}
+	}
+	u, _ := bitvector.Random(70)
+	uA, err := a.VecMul(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uA2, _ := a.Transpose().MulVec(u)
+	if !uA.Equal(uA2) {
+		t.Error("u A != A^T u")
+	}
+	if _, err := a.MulVec(u); err == nil {
+		t.Error("MulVec with wrong length should fail")
+	}
+	if _, err := a.VecMul(v); err == nil {
+		t.Error("VecMul with wrong length should fail")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	a, err := Random(10, 70)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.NumRows() != 10 || a.NumCols() != 70 {
+		t.Errorf("Random dims %d x %d", a.NumRows(), a.NumCols())
+	}
+	if _, err := Random(0, 3); err == nil {
+		t.Error("Random(0, 3) should fail")
+	}
+}
+
+func benchmarkMul(b *testing.B, n int, mul func(x, y *BitMatrix) *BitMatrix) {
+	x, _ := Random(n, n)
+	y, _ := Random(n, n)
+	for b.Loop() {
+		mul(x, y)
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	for _, n := range []int{64, 128, 256, 1024} {
+		if n <= 256 {
+			b.Run(fmt.Sprintf("naive-%d", n), func(b *testing.B) { benchmarkMul(b, n, mulNaive) })
+		}
+		b.Run(fmt.Sprintf("rows-%d", n), func(b *testing.B) {
+			benchmarkMul(b, n, func(x, y *BitMatrix) *BitMatrix { return x.mulRows(y) })
+		})
+		b.Run(fmt.Sprintf("m4rm-%d", n), func(b *testing.B) {
+			benchmarkMul(b, n, func(x, y *BitMatrix) *BitMatrix { return x.mulM4RM(y) })
+		})
+	}
+}
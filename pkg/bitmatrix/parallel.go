@@ -0,0 +1,90 @@
+package bitmatrix
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// numWorkers and parallelThreshold control parallel elimination. They are process-wide
+// and atomic, so they may be changed while other goroutines eliminate; each elimination
+// reads them once at the start. Use SetNumWorkers/SetParallelThreshold.
+var numWorkers, parallelThreshold atomic.Int64
+
+func init() {
+	numWorkers.Store(int64(runtime.GOMAXPROCS(0)))
+	parallelThreshold.Store(1 << 20)
+}
+
+// minRowsPerTask keeps each task big enough to be worth handing to another goroutine.
+const minRowsPerTask = 32
+
+// SetNumWorkers sets how many goroutines share the row updates in Gaussian elimination.
+// n <= 0 means runtime.GOMAXPROCS(0); n == 1 means always eliminate serially.
+func SetNumWorkers(n int) {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	numWorkers.Store(int64(n))
+}
+
+// SetParallelThreshold sets the matrix size, in entries (rows times columns), at and
+// above which Gaussian elimination runs in parallel. Smaller matrices are eliminated
+// serially since the synchronization per pivot would outweigh the work.
+func SetParallelThreshold(numEntries int) { parallelThreshold.Store(int64(numEntries)) }
+
+// rowPool is a fixed set of goroutines that apply one function to disjoint ranges of
+// rows. It lives for one elimination so goroutines are not started per pivot.
+type rowPool struct {
+	numWorkers int
+	tasks      chan rowTask
+}
+
+type rowTask struct {
+	lo, hi int
+	f      func(lo, hi int)
+	done   *sync.WaitGroup
+}
+
+// newRowPool returns a pool for eliminating m, or nil if m should be eliminated
+// serially.
+func (m *BitMatrix) newRowPool() *rowPool {
+	workers := int(numWorkers.Load())
+	if workers <= 1 || int64(m.numRows)*int64(m.numCols) < parallelThreshold.Load() {
+		return nil
+	}
+	p := &rowPool{numWorkers: workers, tasks: make(chan rowTask, workers)}
+	for w := 0; w < workers; w++ {
+		go func() {
+			for t := range p.tasks {
+				t.f(t.lo, t.hi)
+				t.done.Done()
+			}
+		}()
+	}
+	return p
+}
+
+// run calls f on a split of [lo, hi) and returns when all parts are done. A nil pool,
+// or too few rows to split, runs f(lo, hi) directly.
+func (p *rowPool) run(lo, hi int, f func(lo, hi int)) {
+	count := hi - lo
+	if p == nil || count < 2*minRowsPerTask {
+		f(lo, hi)
+		return
+	}
+	parts := min(p.numWorkers, count/minRowsPerTask)
+	chunk := (count + parts - 1) / parts
+	var wg sync.WaitGroup
+	for start := lo; start < hi; start += chunk {
+		wg.Add(1)
+		p.tasks <- rowTask{lo: start, hi: min(start+chunk, hi), f: f, done: &wg}
+	}
+	wg.Wait()
+}
+
+func (p *rowPool) close() {
+	if p != nil {
+		close(p.tasks)
+	}
+}
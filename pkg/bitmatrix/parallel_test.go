@@ -0,0 +1,119 @@
+package bitmatrix
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// withParallel runs f with elimination forced onto workers goroutines for any size.
+func withParallel(workers int, f func()) {
+	savedWorkers, savedThreshold := numWorkers.Load(), parallelThreshold.Load()
+	defer func() {
+		numWorkers.Store(savedWorkers)
+		parallelThreshold.Store(savedThreshold)
+	}()
+	numWorkers.Store(int64(workers))
+	parallelThreshold.Store(0)
+	f()
+}
+
+func TestParallelMatchesSerial(t *testing.T) {
+	dims := [][2]int{{1, 1}, {70, 70}, {200, 150}, {150, 300}, {300, 1000}}
+	for k, d := range dims {
+		a := randomMatrix(t, d[0], d[1], uint64(k+71))
+		// A repeated block of rows makes sure dependent rows are handled alike.
+		for i := d[0] / 2; i < d[0]; i++ {
+			a.Rows[i] = a.Rows[i-d[0]/2].Clone()
+		}
+
+		var serialRREF *RREF
+		var serialPLE *PLE
+		withParallel(1, func() {
+			serialRREF = a.ReducedRowEchelonForm()
+			serialPLE = a.PLE()
+		})
+		serialKernel, _ := a.KernelBasis()
+
+		for _, workers := range []int{2, 3, 8} {
+			withParallel(workers, func() {
+				name := fmt.Sprintf("%dx%d/%d workers", d[0], d[1], workers)
+				rref := a.ReducedRowEchelonForm()
+				if !rref.R.Equal(serialRREF.R) || !slices.Equal(rref.Pivots, serialRREF.Pivots) ||
+					!slices.Equal(rref.Perm, serialRREF.Perm) {
+					t.Errorf("%s: RREF differs from serial", name)
+				}
+				ple := a.PLE()
+				if !ple.L.Equal(serialPLE.L) || !ple.E.Equal(serialPLE.E) ||
+					!slices.Equal(ple.Perm, serialPLE.Perm) {
+					t.Errorf("%s: PLE differs from serial", name)
+				}
+				if got := a.Rank(); got != serialRREF.Rank() {
+					t.Errorf("%s: rank %d, want %d", name, got, serialRREF.Rank())
+				}
+				kernel, _ := a.KernelBasis()
+				if (kernel == nil) != (serialKernel == nil) || (kernel != nil && !kernel.Equal(serialKernel)) {
+					t.Errorf("%s: kernel basis differs from serial", name)
+				}
+			})
+		}
+	}
+}
+
+func TestSetNumWorkers(t *testing.T) {
+	saved := numWorkers.Load()
+	defer numWorkers.Store(saved)
+	SetNumWorkers(3)
+	if n := numWorkers.Load(); n != 3 {
+		t.Errorf("numWorkers %d, want 3", n)
+	}
+	SetNumWorkers(0)
+	if n := numWorkers.Load(); n < 1 {
+		t.Errorf("numWorkers %d after reset", n)
+	}
+}
+
+// TestSetNumWorkersConcurrent changes the settings while other goroutines eliminate; run
+// it with -race.
+func TestSetNumWorkersConcurrent(t *testing.T) {
+	savedWorkers, savedThreshold := numWorkers.Load(), parallelThreshold.Load()
+	defer func() {
+		numWorkers.Store(savedWorkers)
+		parallelThreshold.Store(savedThreshold)
+	}()
+	SetParallelThreshold(0)
+	a := randomMatrix(t, 100, 100, 5)
+	want := a.Rank()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if got := a.Rank(); got != want {
+					t.Errorf("rank %d, want %d", got, want)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		SetNumWorkers(1 + i%4)
+	}
+	wg.Wait()
+}
+
+func BenchmarkRank(b *testing.B) {
+	for _, n := range []int{512, 1024, 2048} {
+		a, _ := Random(n, n)
+		for _, workers := range []int{1, 4} {
+			b.Run(fmt.Sprintf("%d-workers-%d", n, workers), func(b *testing.B) {
+				withParallel(workers, func() {
+					for b.Loop() {
+						a.Rank()
+					}
+				})
+			})
+		}
+	}
+}
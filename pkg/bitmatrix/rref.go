@@ -0,0 +1,142 @@
+package bitmatrix
+
+import (
+	"fmt"
+
+	"github.com/johnkerl/goffl/pkg/bitvector"
+)
+
+// RREF is a reduced row echelon form together with how it was reached.
+type RREF struct {
+	// R is the reduced row echelon form. Its first len(Pivots) rows are nonzero.
+	R *BitMatrix
+	// Pivots[i] is the pivot column of row i of R.
+	Pivots []int
+	// Perm[i] is the row of the original matrix that was swapped into row i.
+	Perm []int
+}
+
+func (r *RREF) Rank() int { return len(r.Pivots) }
+
+// ReducedRowEchelonForm returns the reduced row echelon form of m along with pivot
+// columns and row permutation. Unlike RowEchelonForm, m is not modified.
+func (m *BitMatrix) ReducedRowEchelonForm() *RREF {
+	rr := m.clone()
+	perm := identityPerm(m.numRows)
+	pivots := rr.reducedRowEchelon(perm)
+	return &RREF{R: rr, Pivots: pivots, Perm: perm}
+}
+
+func identityPerm(n int) []int {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	return perm
+}
+
+// RowSpaceBasis returns a basis for the row space as the rows of a matrix, or nil if m
+// is zero. The basis is the nonzero rows of the reduced row echelon form.
+func (m *BitMatrix) RowSpaceBasis() *BitMatrix {
+	rref := m.ReducedRowEchelonForm()
+	rank := rref.Rank()
+	if rank == 0 {
+		return nil
+	}
+	return &BitMatrix{numRows: rank, numCols: m.numCols, Rows: rref.R.Rows[:rank]}
+}
+
+// ImageBasis returns a basis for the column space (image) as the rows of a matrix, each
+// of length NumRows(), or nil if m is zero. The basis is the columns of m at the pivot
+// positions.
+func (m *BitMatrix) ImageBasis() *BitMatrix {
+	rref := m.ReducedRowEchelonForm()
+	rank := rref.Rank()
+	if rank == 0 {
+		return nil
+	}
+	t := m.Transpose()
+	rows := make([]*bitvector.BitVector, rank)
+	for i, col := range rref.Pivots {
+		rows[i] = t.Rows[col]
+	}
+	return &BitMatrix{numRows: rank, numCols: m.numRows, Rows: rows}
+}
+
+// PLE is a decomposition P A = L E of an m x n matrix A, where P permutes rows, L is
+// m x m unit lower triangular, and E is m x n in row echelon form. Once computed it
+// solves A x = b for any number of right-hand sides at the cost of two triangular
+// substitutions each.
+type PLE struct {
+	// Perm[i] is the row of A that P moves to row i.
+	Perm []int
+	L    *BitMatrix
+	E    *BitMatrix
+	// Pivots[i] is the pivot column of row i of E.
+	Pivots []int
+}
+
+// PLE returns the PLE decomposition of m. m is not modified.
+func (m *BitMatrix) PLE() *PLE {
+	e := m.clone()
+	l, _ := New(m.numRows, m.numRows)
+	perm := identityPerm(m.numRows)
+	pivots := e.rowReduceBelow(perm, l)
+	return &PLE{Perm: perm, L: l, E: e, Pivots: pivots}
+}
+
+func (d *PLE) Rank() int { return len(d.Pivots) }
+
+// P returns the permutation as a matrix.
+func (d *PLE) P() *BitMatrix {
+	p, _ := New(len(d.Perm), len(d.Perm))
+	for i, j := range d.Perm {
+		p.Rows[i].Set(j, 1)
+	}
+	return p
+}
+
+// Solve finds one x with A x = b, setting free variables to zero, or returns an error
+// if the system is inconsistent. Use A.KernelBasis() for the rest of the solutions.
+func (d *PLE) Solve(b *bitvector.BitVector) (*bitvector.BitVector, error) {
+	n := len(d.Perm)
+	if b.NumBits() != n {
+		return nil, fmt.Errorf("PLE solve: vector length %d != %d rows", b.NumBits(), n)
+	}
+
+	// Forward substitution: L y = P b.
+	y, _ := bitvector.New(n)
+	for i := 0; i < n; i++ {
+		yi, _ := b.Get(d.Perm[i])
+		for j := range d.L.Rows[i].SetPositions() {
+			if j >= i {
+				break
+			}
+			yj, _ := y.Get(j)
+			yi ^= yj
+		}
+		y.Set(i, yi)
+	}
+
+	// Rows of E past the rank are zero, so y must be too.
+	rank := d.Rank()
+	for i := rank; i < n; i++ {
+		if yi, _ := y.Get(i); yi != 0 {
+			return nil, fmt.Errorf("PLE solve: system is inconsistent")
+		}
+	}
+
+	// Back substitution: E x = y.
+	x, _ := bitvector.New(d.E.numCols)
+	for i := rank - 1; i >= 0; i-- {
+		xi, _ := y.Get(i)
+		for j := range d.E.Rows[i].SetPositions() {
+			if j > d.Pivots[i] {
+				xj, _ := x.Get(j)
+				xi ^= xj
+			}
+		}
+		x.Set(d.Pivots[i], xi)
+	}
+	return x, nil
+}
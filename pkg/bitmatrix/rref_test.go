@@ -0,0 +1,135 @@
+package bitmatrix
+
+import (
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/bitvector"
+)
+
+func TestReducedRowEchelonForm(t *testing.T) {
+	a := randomMatrix(t, 40, 100, 21)
+	orig := a.clone()
+	rref := a.ReducedRowEchelonForm()
+	if !a.Equal(orig) {
+		t.Fatal("ReducedRowEchelonForm modified its receiver")
+	}
+	if rref.Rank() != a.Rank() {
+		t.Errorf("rank %d, want %d", rref.Rank(), a.Rank())
+	}
+	for i, col := range rref.Pivots {
+		if got := rref.R.Rows[i].FindLeaderPos(); got != col {
+			t.Errorf("row %d leader %d, pivot %d", i, got, col)
+		}
+		for r := 0; r < rref.Rank(); r++ {
+			if v, _ := rref.R.Rows[r].Get(col); (v == 1) != (r == i) {
+				t.Errorf("pivot column %d not a unit column at row %d", col, r)
+			}
+		}
+	}
+	seen := make([]bool, a.NumRows())
+	for _, j := range rref.Perm {
+		seen[j] = true
+	}
+	for j, ok := range seen {
+		if !ok {
+			t.Errorf("Perm is missing row %d", j)
+		}
+	}
+
+	b := a.clone()
+	b.RowEchelonForm()
+	if !b.Equal(rref.R) {
+		t.Error("RowEchelonForm and ReducedRowEchelonForm disagree")
+	}
+}
+
+func TestRowSpaceAndImage(t *testing.T) {
+	// Rank-deficient: the last rows are sums of earlier ones.
+	a := randomMatrix(t, 30, 80, 22)
+	for i := 20; i < 30; i++ {
+		a.Rows[i] = a.Rows[i-20].Clone()
+		a.Rows[i].XorInPlace(a.Rows[i-19])
+	}
+	rank := a.Rank()
+
+	rs := a.RowSpaceBasis()
+	if rs.NumRows() != rank || rs.Rank() != rank {
+		t.Errorf("row space basis has %d rows of rank %d, want %d", rs.NumRows(), rs.Rank(), rank)
+	}
+	// Each row of a lies in the row space: stacking it on doesn't raise the rank.
+	for i := 0; i < a.NumRows(); i++ {
+		stacked := &BitMatrix{numRows: rank + 1, numCols: a.NumCols(),
+			Rows: append(append([]*bitvector.BitVector{}, rs.Rows...), a.Rows[i])}
+		if stacked.Rank() != rank {
+			t.Fatalf("row %d of A is not in RowSpaceBasis", i)
+		}
+	}
+
+	im := a.ImageBasis()
+	if im.NumRows() != rank || im.NumCols() != a.NumRows() || im.Rank() != rank {
+		t.Errorf("image basis is %d x %d of rank %d, want rank %d", im.NumRows(), im.NumCols(), im.Rank(), rank)
+	}
+	// A x lies in the image for random x.
+	for k := 0; k < 5; k++ {
+		x, _ := bitvector.Random(a.NumCols())
+		y, _ := a.MulVec(x)
+		stacked := &BitMatrix{numRows: rank + 1, numCols: a.NumRows(),
+			Rows: append(append([]*bitvector.BitVector{}, im.Rows...), y)}
+		if stacked.Rank() != rank {
+			t.Fatal("A x is not in ImageBasis")
+		}
+	}
+
+	z, _ := New(3, 4)
+	if z.RowSpaceBasis() != nil || z.ImageBasis() != nil {
+		t.Error("zero matrix should have nil row space and image bases")
+	}
+}
+
+func TestPLE(t *testing.T) {
+	dims := [][2]int{{1, 1}, {5, 9}, {64, 64}, {90, 40}, {40, 130}}
+	for k, d := range dims {
+		a := randomMatrix(t, d[0], d[1], uint64(k+31))
+		ple := a.PLE()
+		pa, _ := ple.P().Mul(a)
+		le, _ := ple.L.Mul(ple.E)
+		if !pa.Equal(le) {
+			t.Errorf("%dx%d: P A != L E", d[0], d[1])
+		}
+		for i := 0; i < d[0]; i++ {
+			if v, _ := ple.L.Rows[i].Get(i); v != 1 {
+				t.Fatalf("%dx%d: L not unit diagonal", d[0], d[1])
+			}
+			for j := range ple.L.Rows[i].SetPositions() {
+				if j > i {
+					t.Fatalf("%dx%d: L not lower triangular", d[0], d[1])
+				}
+			}
+		}
+		if ple.Rank() != a.Rank() {
+			t.Errorf("%dx%d: PLE rank %d, want %d", d[0], d[1], ple.Rank(), a.Rank())
+		}
+
+		// Reuse the one factorization for several right-hand sides.
+		for r := 0; r < 4; r++ {
+			want, _ := bitvector.Random(d[1])
+			b, _ := a.MulVec(want)
+			x, err := ple.Solve(b)
+			if err != nil {
+				t.Fatalf("%dx%d: %v", d[0], d[1], err)
+			}
+			if got, _ := a.MulVec(x); !got.Equal(b) {
+				t.Errorf("%dx%d: A x != b", d[0], d[1])
+			}
+		}
+	}
+
+	a, _ := New(2, 2)
+	a.Rows[0].Set(0, 1)
+	a.Rows[1].Set(0, 1)
+	b, _ := bitvector.New(2)
+	b.Set(0, 1)
+	if _, err := a.PLE().Solve(b); err == nil {
+		t.Error("PLE Solve of inconsistent system should fail")
+	}
+}
@@ -0,0 +1,78 @@
+package bitmatrix
+
+import (
+	"fmt"
+
+	"github.com/johnkerl/goffl/pkg/bitvector"
+)
+
+// augment returns the matrix [m | other] having m's columns first, then other's.
+func (m *BitMatrix) augment(other *BitMatrix) *BitMatrix {
+	rows := make([]*bitvector.BitVector, m.numRows)
+	for i := 0; i < m.numRows; i++ {
+		rows[i] = m.Rows[i].Concat(other.Rows[i])
+	}
+	return &BitMatrix{numRows: m.numRows, numCols: m.numCols + other.numCols, Rows: rows}
+}
+
+// Solve finds x with m * x = b. It returns one particular solution along with a kernel
+// basis as from KernelBasis (nil when the kernel is trivial); every solution is x plus
+// a sum of kernel rows. Returns an error if the system is inconsistent.
+func (m *BitMatrix) Solve(b *bitvector.BitVector) (x *bitvector.BitVector, kernel *BitMatrix, err error) {
+	if b.NumBits() != m.numRows {
+		return nil, nil, fmt.Errorf("BitMatrix solve: vector length %d != %d rows", b.NumBits(), m.numRows)
+	}
+	bcol, _ := New(m.numRows, 1)
+	for i := range b.SetPositions() {
+		bcol.Rows[i].Set(0, 1)
+	}
+	rr := m.augment(bcol)
+	pivots := rr.reducedRowEchelon(nil)
+
+	x, _ = bitvector.New(m.numCols)
+	for i, col := range pivots {
+		if col == m.numCols {
+			return nil, nil, fmt.Errorf("BitMatrix solve: system is inconsistent")
+		}
+		x.Set(col, int(bitAt(rr.Rows[i], m.numCols)))
+	}
+
+	kernel, err = m.KernelBasis()
+	if err != nil {
+		return nil, nil, err
+	}
+	return x, kernel, nil
+}
+
+// Inverse returns the inverse of a square matrix, or an error if m is not square or is
+// singular.
+func (m *BitMatrix) Inverse() (*BitMatrix, error) {
+	if m.numRows != m.numCols {
+		return nil, fmt.Errorf("BitMatrix inverse: matrix is %d x %d, not square", m.numRows, m.numCols)
+	}
+	n := m.numRows
+	id, _ := Identity(n)
+	rr := m.augment(id)
+	rr.RowEchelonForm()
+
+	inv := &BitMatrix{numRows: n, numCols: n, Rows: make([]*bitvector.BitVector, n)}
+	for i := 0; i < n; i++ {
+		if rr.Rows[i].FindLeaderPos() != i {
+			return nil, fmt.Errorf("BitMatrix inverse: matrix is singular")
+		}
+		inv.Rows[i], _ = rr.Rows[i].Slice(n, 2*n)
+	}
+	return inv, nil
+}
+
+// Det returns the determinant of a square matrix, which over GF(2) is 1 exactly when the
+// matrix is invertible.
+func (m *BitMatrix) Det() (int, error) {
+	if m.numRows != m.numCols {
+		return 0, fmt.Errorf("BitMatrix det: matrix is %d x %d, not square", m.numRows, m.numCols)
+	}
+	if m.Rank() == m.numRows {
+		return 1, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,97 @@
+package bitmatrix
+
+import (
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/bitvector"
+)
+
+func TestSolve(t *testing.T) {
+	dims := [][2]int{{4, 4}, {30, 70}, {70, 30}, {100, 100}, {65, 200}}
+	for k, d := range dims {
+		a := randomMatrix(t, d[0], d[1], uint64(k+11))
+		want, _ := bitvector.Random(d[1])
+		b, _ := a.MulVec(want)
+		x, kernel, err := a.Solve(b)
+		if err != nil {
+			t.Fatalf("%dx%d: %v", d[0], d[1], err)
+		}
+		if got, _ := a.MulVec(x); !got.Equal(b) {
+			t.Errorf("%dx%d: A x != b", d[0], d[1])
+		}
+		nullity := 0
+		if kernel != nil {
+			nullity = kernel.NumRows()
+			// Any particular solution plus a kernel vector is again a solution.
+			y, _ := x.Xor(kernel.Rows[nullity-1])
+			if got, _ := a.MulVec(y); !got.Equal(b) {
+				t.Errorf("%dx%d: A (x + k) != b", d[0], d[1])
+			}
+		}
+		if a.Rank()+nullity != d[1] {
+			t.Errorf("%dx%d: rank + nullity != columns", d[0], d[1])
+		}
+	}
+}
+
+func TestSolveInconsistent(t *testing.T) {
+	// Rows 0 and 1 are equal but b differs there.
+	a, _ := New(2, 3)
+	a.Rows[0].Set(0, 1)
+	a.Rows[1].Set(0, 1)
+	b, _ := bitvector.New(2)
+	b.Set(1, 1)
+	if _, _, err := a.Solve(b); err == nil {
+		t.Error("Solve of inconsistent system should fail")
+	}
+	short, _ := bitvector.New(3)
+	if _, _, err := a.Solve(short); err == nil {
+		t.Error("Solve with wrong vector length should fail")
+	}
+}
+
+func TestInverseDet(t *testing.T) {
+	for _, n := range []int{1, 5, 64, 65, 150} {
+		found := false
+		for seed := uint64(1); seed < 50 && !found; seed++ {
+			a := randomMatrix(t, n, n, seed)
+			det, err := a.Det()
+			if err != nil {
+				t.Fatal(err)
+			}
+			inv, err := a.Inverse()
+			if det == 0 {
+				if err == nil {
+					t.Errorf("n=%d: Inverse of singular matrix should fail", n)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("n=%d: %v", n, err)
+			}
+			id, _ := Identity(n)
+			if got, _ := a.Mul(inv); !got.Equal(id) {
+				t.Errorf("n=%d: A A^-1 != I", n)
+			}
+			if got, _ := inv.Mul(a); !got.Equal(id) {
+				t.Errorf("n=%d: A^-1 A != I", n)
+			}
+			found = true
+		}
+		if !found {
+			t.Errorf("n=%d: no invertible random matrix found", n)
+		}
+	}
+
+	z, _ := New(3, 3)
+	if det, _ := z.Det(); det != 0 {
+		t.Errorf("Det(0) = %d, want 0", det)
+	}
+	r, _ := New(2, 3)
+	if _, err := r.Det(); err == nil {
+		t.Error("Det of non-square matrix should fail")
+	}
+	if _, err := r.Inverse(); err == nil {
+		t.Error("Inverse of non-square matrix should fail")
+	}
+}
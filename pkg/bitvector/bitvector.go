@@ -2,6 +2,9 @@ package bitvector
 
 import (
 	"fmt"
+	"iter"
+	"math/rand"
+
 	"github.com/johnkerl/goffl/pkg/bitarith"
 )
 
@@ -12,41 +15,79 @@ var writeHex bool
 func SetHexOutput()    { writeHex = true }
 func SetBinaryOutput() { writeHex = false }
 
-const maxUint64 = 0xFFFFFFFFFFFFFFFF
+const bitsPerWord = 64
 
-// BitVector is a fixed-length bit vector. Bit position 0 is the LSB (rightmost).
+// BitVector is a fixed-length bit vector of any size. Bit position 0 is the LSB
+// (rightmost). Bit j lives in Words[j/64] at position j%64; bits at positions
+// >= NumBits() in the last word are kept zero.
 type BitVector struct {
 	numBits int
-	Bits    uint64
+	Words   []uint64
 }
 
 func New(numBits int) (*BitVector, error) {
 	if numBits <= 0 {
 		return nil, fmt.Errorf("BitVector: size must be > 0; got %d", numBits)
 	}
-	return &BitVector{numBits: numBits}, nil
+	return &BitVector{numBits: numBits, Words: make([]uint64, numWordsFor(numBits))}, nil
 }
 
-func (v *BitVector) NumBits() int { return v.numBits }
+func numWordsFor(numBits int) int {
+	return (numBits + bitsPerWord - 1) / bitsPerWord
+}
 
-func (v *BitVector) String() string {
-	mask := uint64(1<<v.numBits) - 1
-	if v.numBits == 64 {
-		mask = maxUint64
+func (v *BitVector) NumBits() int  { return v.numBits }
+func (v *BitVector) NumWords() int { return len(v.Words) }
+
+// lastWordMask is the mask of valid bits in the highest word.
+func (v *BitVector) lastWordMask() uint64 {
+	r := v.numBits % bitsPerWord
+	if r == 0 {
+		return ^uint64(0)
 	}
-	x := v.Bits & mask
+	return (uint64(1) << r) - 1
+}
+
+// clearPadding zeroes the unused high bits of the last word.
+func (v *BitVector) clearPadding() {
+	v.Words[len(v.Words)-1] &= v.lastWordMask()
+}
+
+func (v *BitVector) checkSameLength(other *BitVector, op string) error {
+	if v.numBits != other.numBits {
+		return fmt.Errorf("BitVector %s: length mismatch %d vs %d", op, v.numBits, other.numBits)
+	}
+	return nil
+}
+
+// Clone returns a deep copy of v.
+func (v *BitVector) Clone() *BitVector {
+	words := make([]uint64, len(v.Words))
+	copy(words, v.Words)
+	return &BitVector{numBits: v.numBits, Words: words}
+}
+
+func (v *BitVector) IsZero() bool {
+	for _, w := range v.Words {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *BitVector) String() string {
 	if writeHex {
-		width := (v.numBits + 3) >> 2
-		return fmt.Sprintf("%0*x", width, x)
+		return v.HexString()
 	}
-	return fmt.Sprintf("%0*b", v.numBits, x)
+	return v.BinaryString()
 }
 
 func (v *BitVector) Get(j int) (int, error) {
 	if j < 0 || j >= v.numBits {
 		return 0, fmt.Errorf("index %d out of bounds 0..%d", j, v.numBits-1)
 	}
-	return int((v.Bits >> j) & 1), nil
+	return int((v.Words[j/bitsPerWord] >> (j % bitsPerWord)) & 1), nil
 }
 
 func (v *BitVector) Set(j int, val int) error {
@@ -54,9 +95,9 @@ func (v *BitVector) Set(j int, val int) error {
 		return fmt.Errorf("index %d out of bounds 0..%d", j, v.numBits-1)
 	}
 	if val&1 == 1 {
-		v.Bits |= 1 << j
+		v.Words[j/bitsPerWord] |= 1 << (j % bitsPerWord)
 	} else {
-		v.Bits &^= 1 << j
+		v.Words[j/bitsPerWord] &^= 1 << (j % bitsPerWord)
 	}
 	return nil
 }
@@ -65,11 +106,235 @@ func (v *BitVector) ToggleElement(j int) error {
 	if j < 0 || j >= v.numBits {
 		return fmt.Errorf("index %d out of bounds 0..%d", j, v.numBits-1)
 	}
-	v.Bits ^= 1 << j
+	v.Words[j/bitsPerWord] ^= 1 << (j % bitsPerWord)
 	return nil
 }
 
 // FindLeaderPos returns the position of the lowest set bit (for row-reduction), or -1 if zero.
 func (v *BitVector) FindLeaderPos() int {
-	return bitarith.LsbPos(v.Bits)
+	for i, w := range v.Words {
+		if w != 0 {
+			return i*bitsPerWord + bitarith.LsbPos(w)
+		}
+	}
+	return -1
+}
+
+func (v *BitVector) Equal(other *BitVector) bool {
+	if v.numBits != other.numBits {
+		return false
+	}
+	for k := range v.Words {
+		if v.Words[k] != other.Words[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *BitVector) And(other *BitVector) (*BitVector, error) {
+	if err := v.checkSameLength(other, "and"); err != nil {
+		return nil, err
+	}
+	rv := v.Clone()
+	for k := range rv.Words {
+		rv.Words[k] &= other.Words[k]
+	}
+	return rv, nil
+}
+
+func (v *BitVector) Or(other *BitVector) (*BitVector, error) {
+	if err := v.checkSameLength(other, "or"); err != nil {
+		return nil, err
+	}
+	rv := v.Clone()
+	for k := range rv.Words {
+		rv.Words[k] |= other.Words[k]
+	}
+	return rv, nil
+}
+
+func (v *BitVector) Xor(other *BitVector) (*BitVector, error) {
+	rv := v.Clone()
+	if err := rv.XorInPlace(other); err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// XorInPlace sets v ^= other. This is vector addition over GF(2).
+func (v *BitVector) XorInPlace(other *BitVector) error {
+	if err := v.checkSameLength(other, "xor"); err != nil {
+		return err
+	}
+	for k := range v.Words {
+		v.Words[k] ^= other.Words[k]
+	}
+	return nil
+}
+
+func (v *BitVector) Not() *BitVector {
+	rv := v.Clone()
+	for k := range rv.Words {
+		rv.Words[k] = ^rv.Words[k]
+	}
+	rv.clearPadding()
+	return rv
+}
+
+// Dot returns the inner product of v and other over GF(2), i.e. the parity of v AND other.
+func (v *BitVector) Dot(other *BitVector) (int, error) {
+	if err := v.checkSameLength(other, "dot"); err != nil {
+		return 0, err
+	}
+	var acc uint64
+	for k := range v.Words {
+		acc ^= v.Words[k] & other.Words[k]
+	}
+	return bitarith.Ones(acc) & 1, nil
+}
+
+// Weight returns the Hamming weight (number of set bits).
+func (v *BitVector) Weight() int {
+	n := 0
+	for _, w := range v.Words {
+		n += bitarith.Ones(w)
+	}
+	return n
+}
+
+// Distance returns the Hamming distance between v and other.
+func (v *BitVector) Distance(other *BitVector) (int, error) {
+	if err := v.checkSameLength(other, "distance"); err != nil {
+		return 0, err
+	}
+	n := 0
+	for k := range v.Words {
+		n += bitarith.Ones(v.Words[k] ^ other.Words[k])
+	}
+	return n, nil
+}
+
+// ShiftLeft returns v shifted toward higher bit positions by k, dropping bits shifted past
+// the top and filling with zeros. Negative k shifts right.
+func (v *BitVector) ShiftLeft(k int) *BitVector {
+	rv := &BitVector{numBits: v.numBits, Words: make([]uint64, len(v.Words))}
+	// Shifting by NumBits() or more either way clears every bit. Checking that first
+	// also keeps -k below from overflowing at math.MinInt.
+	if k >= v.numBits || k <= -v.numBits {
+		return rv
+	}
+	if k < 0 {
+		return v.ShiftRight(-k)
+	}
+	wordShift, bitShift := k/bitsPerWord, uint(k%bitsPerWord)
+	for i := len(v.Words) - 1; i >= wordShift; i-- {
+		w := v.Words[i-wordShift] << bitShift
+		if bitShift != 0 && i-wordShift-1 >= 0 {
+			w |= v.Words[i-wordShift-1] >> (bitsPerWord - bitShift)
+		}
+		rv.Words[i] = w
+	}
+	rv.clearPadding()
+	return rv
+}
+
+// ShiftRight returns v shifted toward lower bit positions by k, filling with zeros.
+// Negative k shifts left.
+func (v *BitVector) ShiftRight(k int) *BitVector {
+	rv := &BitVector{numBits: v.numBits, Words: make([]uint64, len(v.Words))}
+	// Shifting by NumBits() or more either way clears every bit. Checking that first
+	// also keeps -k below from overflowing at math.MinInt.
+	if k >= v.numBits || k <= -v.numBits {
+		return rv
+	}
+	if k < 0 {
+		return v.ShiftLeft(-k)
+	}
+	wordShift, bitShift := k/bitsPerWord, uint(k%bitsPerWord)
+	for i := 0; i+wordShift < len(v.Words); i++ {
+		w := v.Words[i+wordShift] >> bitShift
+		if bitShift != 0 && i+wordShift+1 < len(v.Words) {
+			w |= v.Words[i+wordShift+1] << (bitsPerWord - bitShift)
+		}
+		rv.Words[i] = w
+	}
+	return rv
+}
+
+// RotateLeft returns v rotated toward higher bit positions by k (mod NumBits()).
+// Negative k rotates right.
+func (v *BitVector) RotateLeft(k int) *BitVector {
+	k %= v.numBits
+	if k < 0 {
+		k += v.numBits
+	}
+	if k == 0 {
+		return v.Clone()
+	}
+	rv := v.ShiftLeft(k)
+	lo := v.ShiftRight(v.numBits - k)
+	for i := range rv.Words {
+		rv.Words[i] |= lo.Words[i]
+	}
+	return rv
+}
+
+// RotateRight returns v rotated toward lower bit positions by k (mod NumBits()).
+func (v *BitVector) RotateRight(k int) *BitVector {
+	return v.RotateLeft(-(k % v.numBits))
+}
+
+// Slice returns the bits at positions lo (inclusive) through hi (exclusive) as a new
+// vector of length hi-lo, with bit lo landing at position 0.
+func (v *BitVector) Slice(lo, hi int) (*BitVector, error) {
+	if lo < 0 || hi > v.numBits || lo >= hi {
+		return nil, fmt.Errorf("BitVector slice: bounds [%d, %d) invalid for length %d", lo, hi, v.numBits)
+	}
+	shifted := v.ShiftRight(lo)
+	rv := &BitVector{numBits: hi - lo, Words: shifted.Words[:numWordsFor(hi-lo)]}
+	rv.clearPadding()
+	return rv, nil
+}
+
+// Concat returns the vector of length v.NumBits()+other.NumBits() having v in the low
+// positions and other above it.
+func (v *BitVector) Concat(other *BitVector) *BitVector {
+	n := v.numBits + other.numBits
+	rv := &BitVector{numBits: n, Words: make([]uint64, numWordsFor(n))}
+	copy(rv.Words, v.Words)
+	hi := &BitVector{numBits: n, Words: make([]uint64, numWordsFor(n))}
+	copy(hi.Words, other.Words)
+	hi = hi.ShiftLeft(v.numBits)
+	for i := range rv.Words {
+		rv.Words[i] |= hi.Words[i]
+	}
+	return rv
+}
+
+// SetPositions returns an iterator over the positions of set bits, in increasing order.
+func (v *BitVector) SetPositions() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i, w := range v.Words {
+			for w != 0 {
+				if !yield(i*bitsPerWord + bitarith.LsbPos(w)) {
+					return
+				}
+				w &= w - 1
+			}
+		}
+	}
+}
+
+// Random returns a uniformly random vector of the given length.
+func Random(numBits int) (*BitVector, error) {
+	v, err := New(numBits)
+	if err != nil {
+		return nil, err
+	}
+	for k := range v.Words {
+		v.Words[k] = rand.Uint64()
+	}
+	v.clearPadding()
+	return v, nil
 }
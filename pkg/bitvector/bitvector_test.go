@@ -0,0 +1,365 @@
+package bitvector
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	if _, err := New(0); err == nil {
+		t.Error("New(0) should fail")
+	}
+	for _, n := range []int{1, 63, 64, 65, 128, 1000} {
+		v, err := New(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.NumBits(); got != n {
+			t.Errorf("New(%d).NumBits() = %d", n, got)
+		}
+		if got, want := v.NumWords(), (n+63)/64; got != want {
+			t.Errorf("New(%d).NumWords() = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestGetSetWide(t *testing.T) {
+	v, _ := New(200)
+	positions := []int{0, 1, 63, 64, 65, 127, 128, 199}
+	for _, j := range positions {
+		if err := v.Set(j, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for j := 0; j < 200; j++ {
+		want := 0
+		for _, p := range positions {
+			if p == j {
+				want = 1
+			}
+		}
+		if got, _ := v.Get(j); got != want {
+			t.Errorf("Get(%d) = %d, want %d", j, got, want)
+		}
+	}
+	if err := v.Set(200, 1); err == nil {
+		t.Error("Set(200) on 200-bit vector should fail")
+	}
+	if _, err := v.Get(-1); err == nil {
+		t.Error("Get(-1) should fail")
+	}
+	v.Set(64, 0)
+	if got, _ := v.Get(64); got != 0 {
+		t.Errorf("Get(64) after clear = %d, want 0", got)
+	}
+}
+
+func TestToggleAndLeader(t *testing.T) {
+	v, _ := New(300)
+	if got := v.FindLeaderPos(); got != -1 {
+		t.Errorf("FindLeaderPos() on zero = %d, want -1", got)
+	}
+	v.ToggleElement(250)
+	if got := v.FindLeaderPos(); got != 250 {
+		t.Errorf("FindLeaderPos() = %d, want 250", got)
+	}
+	v.ToggleElement(70)
+	if got := v.FindLeaderPos(); got != 70 {
+		t.Errorf("FindLeaderPos() = %d, want 70", got)
+	}
+	v.ToggleElement(70)
+	v.ToggleElement(250)
+	if !v.IsZero() {
+		t.Error("vector should be zero after double toggles")
+	}
+}
+
+func TestString(t *testing.T) {
+	defer SetBinaryOutput()
+	v, _ := New(70)
+	v.Set(0, 1)
+	v.Set(69, 1)
+	SetBinaryOutput()
+	want := "1" + strings.Repeat("0", 68) + "1"
+	if got := v.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	SetHexOutput()
+	if got := v.String(); got != "200000000000000001" {
+		t.Errorf("hex String() = %q, want %q", got, "200000000000000001")
+	}
+
+	w, _ := New(64)
+	w.Set(63, 1)
+	if got := w.String(); got != "8000000000000000" {
+		t.Errorf("hex String() = %q, want %q", got, "8000000000000000")
+	}
+}
+
+func TestClone(t *testing.T) {
+	v, _ := New(100)
+	v.Set(99, 1)
+	c := v.Clone()
+	c.Set(99, 0)
+	if got, _ := v.Get(99); got != 1 {
+		t.Error("Clone() should not share storage")
+	}
+}
+
+func fromString(t *testing.T, s string) *BitVector {
+	t.Helper()
+	v, err := New(len(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range s {
+		v.Set(len(s)-1-i, int(c-'0'))
+	}
+	return v
+}
+
+func randomVector(n int, seed uint64) *BitVector {
+	v, _ := New(n)
+	x := seed
+	for j := 0; j < n; j++ {
+		x = x*6364136223846793005 + 1442695040888963407
+		v.Set(j, int(x>>63))
+	}
+	return v
+}
+
+func TestBooleanOps(t *testing.T) {
+	a := fromString(t, "1100")
+	b := fromString(t, "1010")
+	and, _ := a.And(b)
+	or, _ := a.Or(b)
+	xor, _ := a.Xor(b)
+	if and.String() != "1000" || or.String() != "1110" || xor.String() != "0110" {
+		t.Errorf("and/or/xor = %s/%s/%s", and, or, xor)
+	}
+	if got := a.Not().String(); got != "0011" {
+		t.Errorf("Not() = %s, want 0011", got)
+	}
+
+	w := randomVector(130, 1)
+	if got := w.Not().Not(); !got.Equal(w) {
+		t.Error("Not(Not(w)) != w")
+	}
+	if got := w.Weight() + w.Not().Weight(); got != 130 {
+		t.Errorf("weight(w) + weight(~w) = %d, want 130", got)
+	}
+
+	short, _ := New(3)
+	if _, err := a.And(short); err == nil {
+		t.Error("And with length mismatch should fail")
+	}
+	if _, err := a.Or(short); err == nil {
+		t.Error("Or with length mismatch should fail")
+	}
+	if _, err := a.Xor(short); err == nil {
+		t.Error("Xor with length mismatch should fail")
+	}
+	if _, err := a.Dot(short); err == nil {
+		t.Error("Dot with length mismatch should fail")
+	}
+	if _, err := a.Distance(short); err == nil {
+		t.Error("Distance with length mismatch should fail")
+	}
+}
+
+func TestDotWeightDistance(t *testing.T) {
+	u := randomVector(200, 2)
+	v := randomVector(200, 3)
+	want := 0
+	for j := 0; j < 200; j++ {
+		x, _ := u.Get(j)
+		y, _ := v.Get(j)
+		want ^= x & y
+	}
+	if got, _ := u.Dot(v); got != want {
+		t.Errorf("Dot = %d, want %d", got, want)
+	}
+	x, _ := u.Xor(v)
+	if got, _ := u.Distance(v); got != x.Weight() {
+		t.Errorf("Distance = %d, want %d", got, x.Weight())
+	}
+}
+
+func TestShiftRotate(t *testing.T) {
+	a := fromString(t, "10110")
+	if got := a.ShiftLeft(1).String(); got != "01100" {
+		t.Errorf("ShiftLeft(1) = %s, want 01100", got)
+	}
+	if got := a.ShiftRight(2).String(); got != "00101" {
+		t.Errorf("ShiftRight(2) = %s, want 00101", got)
+	}
+	if got := a.RotateLeft(1).String(); got != "01101" {
+		t.Errorf("RotateLeft(1) = %s, want 01101", got)
+	}
+	if got := a.RotateRight(1).String(); got != "01011" {
+		t.Errorf("RotateRight(1) = %s, want 01011", got)
+	}
+
+	n := 150
+	v := randomVector(n, 4)
+	for _, k := range []int{0, 1, 37, 63, 64, 65, 100, 149, 150, 200} {
+		sl := v.ShiftLeft(k)
+		sr := v.ShiftRight(k)
+		rl := v.RotateLeft(k)
+		for j := 0; j < n; j++ {
+			want := 0
+			if j-k >= 0 {
+				want, _ = v.Get(j - k)
+			}
+			if got, _ := sl.Get(j); got != want {
+				t.Fatalf("ShiftLeft(%d) bit %d = %d, want %d", k, j, got, want)
+			}
+			want = 0
+			if j+k < n {
+				want, _ = v.Get(j + k)
+			}
+			if got, _ := sr.Get(j); got != want {
+				t.Fatalf("ShiftRight(%d) bit %d = %d, want %d", k, j, got, want)
+			}
+			want, _ = v.Get(((j-k)%n + n) % n)
+			if got, _ := rl.Get(j); got != want {
+				t.Fatalf("RotateLeft(%d) bit %d = %d, want %d", k, j, got, want)
+			}
+		}
+		if !rl.RotateRight(k).Equal(v) {
+			t.Fatalf("RotateRight(%d) does not undo RotateLeft", k)
+		}
+		if sl.Weight() > v.Weight() {
+			t.Fatalf("ShiftLeft(%d) leaked padding bits", k)
+		}
+	}
+}
+
+func TestShiftExtremes(t *testing.T) {
+	v := randomVector(150, 6)
+	for _, k := range []int{1, 64, 149} {
+		if !v.ShiftLeft(-k).Equal(v.ShiftRight(k)) || !v.ShiftRight(-k).Equal(v.ShiftLeft(k)) {
+			t.Errorf("shift by -%d should match the opposite shift by %d", k, k)
+		}
+	}
+	for _, k := range []int{math.MinInt, -151, -150, 150, math.MaxInt} {
+		if !v.ShiftLeft(k).IsZero() || !v.ShiftRight(k).IsZero() {
+			t.Errorf("shifts by %d should clear every bit", k)
+		}
+	}
+}
+
+func TestSliceConcat(t *testing.T) {
+	v := randomVector(300, 5)
+	lo, err := v.Slice(0, 77)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hi, err := v.Slice(77, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lo.NumBits() != 77 || hi.NumBits() != 223 {
+		t.Errorf("slice lengths = %d, %d", lo.NumBits(), hi.NumBits())
+	}
+	if got := lo.Concat(hi); !got.Equal(v) {
+		t.Error("Concat of slices != original")
+	}
+	if _, err := v.Slice(10, 10); err == nil {
+		t.Error("empty Slice should fail")
+	}
+	if _, err := v.Slice(0, 301); err == nil {
+		t.Error("out-of-bounds Slice should fail")
+	}
+	a := fromString(t, "101")
+	b := fromString(t, "0011")
+	if got := a.Concat(b).String(); got != "0011101" {
+		t.Errorf("Concat = %s, want 0011101", got)
+	}
+}
+
+func TestSetPositions(t *testing.T) {
+	v, _ := New(200)
+	want := []int{0, 5, 63, 64, 130, 199}
+	for _, j := range want {
+		v.Set(j, 1)
+	}
+	var got []int
+	for j := range v.SetPositions() {
+		got = append(got, j)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("SetPositions() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("SetPositions() = %v, want %v", got, want)
+		}
+	}
+	for j := range v.SetPositions() {
+		if j > 5 {
+			break
+		}
+	}
+}
+
+func TestFormatParse(t *testing.T) {
+	for _, n := range []int{1, 4, 5, 63, 64, 65, 130} {
+		v := randomVector(n, uint64(n))
+		b, err := ParseBinary(v.BinaryString())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !b.Equal(v) {
+			t.Errorf("n=%d: binary round trip failed", n)
+		}
+		h, err := ParseHex(v.HexString(), n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !h.Equal(v) {
+			t.Errorf("n=%d: hex round trip failed", n)
+		}
+	}
+	v, err := ParseHex("0x1F", 5)
+	if err != nil || v.BinaryString() != "11111" {
+		t.Errorf("ParseHex(0x1F, 5) = %v, %v", v, err)
+	}
+	if _, err := ParseHex("20", 5); err == nil {
+		t.Error("ParseHex of too-wide value should fail")
+	}
+	if _, err := ParseHex("g", 5); err == nil {
+		t.Error("ParseHex of non-hex digit should fail")
+	}
+	if _, err := ParseHex("1", 5); err == nil {
+		t.Error("ParseHex with too few digits should fail")
+	}
+	if _, err := ParseHex("1", -1); err == nil {
+		t.Error("ParseHex with negative numBits should fail")
+	}
+	if _, err := ParseBinary("10x"); err == nil {
+		t.Error("ParseBinary of non-binary digit should fail")
+	}
+	if _, err := ParseBinary(""); err == nil {
+		t.Error("ParseBinary of empty string should fail")
+	}
+}
+
+func TestUnmarshalJSONHostile(t *testing.T) {
+	for _, data := range []string{
+		`{"numBits":1000000000000,"hex":"1"}`,
+		`{"numBits":-5,"hex":"1"}`,
+		`{"numBits":0,"hex":"0"}`,
+	} {
+		var v BitVector
+		if err := json.Unmarshal([]byte(data), &v); err == nil {
+			t.Errorf("UnmarshalJSON(%s) should fail", data)
+		}
+	}
+	var v BitVector
+	if err := json.Unmarshal([]byte(`{"numBits":5,"hex":"1f"}`), &v); err != nil || v.BinaryString() != "11111" {
+		t.Errorf("UnmarshalJSON = %v, %v", &v, err)
+	}
+}
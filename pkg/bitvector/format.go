@@ -0,0 +1,120 @@
+package bitvector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/johnkerl/goffl/pkg/bitarith"
+)
+
+// The explicit formatters below do not depend on SetHexOutput/SetBinaryOutput. Like
+// String(), they write bit 0 last (rightmost), so the text reads as a binary or hex
+// number.
+
+// BinaryString returns the bits as '0'/'1' characters, NumBits() of them.
+func (v *BitVector) BinaryString() string {
+	var sb strings.Builder
+	sb.Grow(v.numBits)
+	for j := v.numBits - 1; j >= 0; j-- {
+		sb.WriteByte('0' + byte((v.Words[j/bitsPerWord]>>(j%bitsPerWord))&1))
+	}
+	return sb.String()
+}
+
+// HexString returns the bits as lowercase hex digits, (NumBits()+3)/4 of them.
+func (v *BitVector) HexString() string {
+	var sb strings.Builder
+	width := (v.numBits + 3) >> 2
+	sb.Grow(width)
+	for k := width - 1; k >= 0; k-- {
+		pos := 4 * k
+		nibble := (v.Words[pos/bitsPerWord] >> (pos % bitsPerWord)) & 0xf
+		sb.WriteByte("0123456789abcdef"[nibble])
+	}
+	return sb.String()
+}
+
+// ParseBinary parses '0'/'1' characters as written by BinaryString. The vector has one
+// bit per character.
+func ParseBinary(s string) (*BitVector, error) {
+	v, err := New(len(s))
+	if err != nil {
+		return nil, fmt.Errorf("BitVector parse: empty input")
+	}
+	for i := 0; i < len(s); i++ {
+		j := len(s) - 1 - i
+		switch s[i] {
+		case '0':
+		case '1':
+			v.Words[j/bitsPerWord] |= 1 << (j % bitsPerWord)
+		default:
+			return nil, fmt.Errorf("BitVector parse: invalid binary digit %q", s[i])
+		}
+	}
+	return v, nil
+}
+
+// ParseHex parses hex digits as written by HexString into a vector of numBits bits. An
+// optional 0x prefix is accepted, and there must be at least (numBits+3)/4 digits, so
+// that numBits is checked against the input before anything is allocated; bits at or
+// above numBits must be zero.
+func ParseHex(s string, numBits int) (*BitVector, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if s == "" {
+		return nil, fmt.Errorf("BitVector parse: empty input")
+	}
+	if numBits > 4*len(s) {
+		return nil, fmt.Errorf("BitVector parse: %d hex digits cannot hold %d bits", len(s), numBits)
+	}
+	v, err := New(numBits)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(s); i++ {
+		var nibble uint64
+		c := s[i]
+		switch {
+		case '0' <= c && c <= '9':
+			nibble = uint64(c - '0')
+		case 'a' <= c && c <= 'f':
+			nibble = uint64(c-'a') + 10
+		case 'A' <= c && c <= 'F':
+			nibble = uint64(c-'A') + 10
+		default:
+			return nil, fmt.Errorf("BitVector parse: invalid hex digit %q", c)
+		}
+		if nibble == 0 {
+			continue
+		}
+		pos := 4 * (len(s) - 1 - i)
+		if pos+bitarith.MsbPos(nibble) >= numBits {
+			return nil, fmt.Errorf("BitVector parse: hex %q does not fit in %d bits", s, numBits)
+		}
+		v.Words[pos/bitsPerWord] |= nibble << (pos % bitsPerWord)
+	}
+	return v, nil
+}
+
+type vectorJSON struct {
+	NumBits int    `json:"numBits"`
+	Hex     string `json:"hex"`
+}
+
+// MarshalJSON writes the vector as {"numBits": n, "hex": "..."}.
+func (v *BitVector) MarshalJSON() ([]byte, error) {
+	return json.Marshal(vectorJSON{NumBits: v.numBits, Hex: v.HexString()})
+}
+
+func (v *BitVector) UnmarshalJSON(data []byte) error {
+	var vj vectorJSON
+	if err := json.Unmarshal(data, &vj); err != nil {
+		return err
+	}
+	parsed, err := ParseHex(vj.Hex, vj.NumBits)
+	if err != nil {
+		return err
+	}
+	*v = *parsed
+	return nil
+}
@@ -0,0 +1,181 @@
+// Package contfrac provides continued fractions: finite expansions and their convergents,
+// best rational approximations, rational reconstruction mod m, the periodic expansions
+// of square roots, and Pell's equation.
+package contfrac
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/johnkerl/goffl/pkg/intarith"
+	"github.com/johnkerl/goffl/pkg/intmod"
+)
+
+// Rational is the fraction Num/Den. Functions here return it in lowest terms with Den > 0.
+type Rational struct {
+	Num, Den int64
+}
+
+func (r Rational) String() string { return fmt.Sprintf("%d/%d", r.Num, r.Den) }
+
+// Expand returns the continued fraction [a_0; a_1, ..., a_k] of num/den, with a_0 =
+// floor(num/den), a_i >= 1 for i >= 1, and a_k >= 2 when k >= 1. It panics if den is 0.
+func Expand(num, den int64) []int64 {
+	if den == 0 {
+		panic("contfrac: division by zero")
+	}
+	if den < 0 {
+		num, den = -num, -den
+	}
+	var cf []int64
+	for den != 0 {
+		q, r := num/den, num%den
+		if r < 0 {
+			q, r = q-1, r+den
+		}
+		cf = append(cf, q)
+		num, den = den, r
+	}
+	return cf
+}
+
+// Convergents returns the convergents p_i/q_i of [a_0; a_1, ...], by
+// p_i = a_i p_(i-1) + p_(i-2) and likewise for q. Those of Expand(num, den) are bounded by
+// |num| and den; for other inputs the terms may overflow.
+func Convergents(cf []int64) []Rational {
+	out := make([]Rational, len(cf))
+	p0, q0, p1, q1 := int64(0), int64(1), int64(1), int64(0)
+	for i, a := range cf {
+		p0, q0, p1, q1 = p1, q1, a*p1+p0, a*q1+q0
+		out[i] = Rational{p1, q1}
+	}
+	return out
+}
+
+// BestApproximation returns the fraction closest to num/den among those with denominator
+// at most maxDen, preferring the smaller denominator on ties. It is either a convergent
+// or the largest semiconvergent (p_(k-1) + j p_k)/(q_(k-1) + j q_k) that fits. den and
+// maxDen must be positive.
+func BestApproximation(num, den, maxDen int64) Rational {
+	if den <= 0 || maxDen <= 0 {
+		panic("contfrac: denominators must be positive")
+	}
+	conv := Convergents(Expand(num, den))
+	k := 0
+	for k+1 < len(conv) && conv[k+1].Den <= maxDen {
+		k++
+	}
+	if k+1 == len(conv) {
+		return conv[k]
+	}
+	prev := Rational{1, 0}
+	if k > 0 {
+		prev = conv[k-1]
+	}
+	// j < a_(k+1) since conv[k+1] does not fit.
+	j := (maxDen - prev.Den) / conv[k].Den
+	semi := Rational{prev.Num + j*conv[k].Num, prev.Den + j*conv[k].Den}
+	if j > 0 && closer(semi, conv[k], num, den) {
+		return semi
+	}
+	return conv[k]
+}
+
+// closer reports whether a is strictly closer than b to num/den, comparing
+// |num*a.Den - den*a.Num| / a.Den with the same for b in big integers.
+func closer(a, b Rational, num, den int64) bool {
+	dist := func(r Rational) *big.Int {
+		d := new(big.Int).Mul(big.NewInt(num), big.NewInt(r.Den))
+		d.Sub(d, new(big.Int).Mul(big.NewInt(den), big.NewInt(r.Num)))
+		return d.Abs(d)
+	}
+	lhs := new(big.Int).Mul(dist(a), big.NewInt(b.Den))
+	rhs := new(big.Int).Mul(dist(b), big.NewInt(a.Den))
+	return lhs.Cmp(rhs) < 0
+}
+
+// RationalReconstruct returns a/b with a = b*x mod m, |a| and b at most
+// sqrt((m-1)/2), which is then unique. See RationalReconstructBounded. For m <= 2 the
+// bound is 0, so only x = 0 has an answer, 0/1.
+func RationalReconstruct(x *intmod.IntMod) (Rational, error) {
+	if m := x.Modulus(); m <= 2 {
+		if x.Residue == 0 {
+			return Rational{0, 1}, nil
+		}
+		return Rational{}, fmt.Errorf("contfrac: modulus %d is too small to reconstruct %d", m, x.Residue)
+	}
+	bound := intarith.Isqrt((x.Modulus() - 1) / 2)
+	return RationalReconstructBounded(x, bound, bound)
+}
+
+// RationalReconstructBounded returns a/b in lowest terms with a = b*x mod m, |a| <= maxNum
+// and 0 < b <= maxDen, or an error if the extended Euclidean algorithm on (m, x) finds
+// none. When 2*maxNum*maxDen < m, there is at most one such fraction. The bounds must
+// satisfy maxNum >= 0 and maxDen >= 1.
+func RationalReconstructBounded(x *intmod.IntMod, maxNum, maxDen int64) (Rational, error) {
+	if maxNum < 0 || maxDen < 1 {
+		return Rational{}, fmt.Errorf("contfrac: bounds must have maxNum >= 0 and maxDen >= 1; got %d, %d",
+			maxNum, maxDen)
+	}
+	m := x.Modulus()
+	// Invariant: r_i = t_i * x mod m, and |t_i| <= m so nothing overflows.
+	r0, r1 := m, x.Residue
+	t0, t1 := int64(0), int64(1)
+	for r1 > maxNum {
+		q := r0 / r1
+		r0, r1 = r1, r0-q*r1
+		t0, t1 = t1, t0-q*t1
+	}
+	if t1 < 0 {
+		r1, t1 = -r1, -t1
+	}
+	if t1 == 0 || t1 > maxDen || intarith.Gcd(r1, t1) != 1 {
+		return Rational{}, fmt.Errorf("contfrac: no fraction with |a| <= %d, b <= %d for %d mod %d",
+			maxNum, maxDen, x.Residue, m)
+	}
+	return Rational{r1, t1}, nil
+}
+
+// SqrtExpansion returns the continued fraction of sqrt(n) for n >= 0 as a_0 and the
+// period a_1, ..., a_L, which ends in 2*a_0. The period is nil when n is a square.
+func SqrtExpansion(n int64) (a0 int64, period []int64) {
+	if n < 0 {
+		panic("contfrac: sqrt of negative number")
+	}
+	a0 = intarith.Isqrt(n)
+	if a0*a0 == n {
+		return a0, nil
+	}
+	// sqrt(n) = a0 + 1/x with x = (sqrt(n) + m)/d; m, d and a stay below 2*sqrt(n).
+	m, d, a := int64(0), int64(1), a0
+	for a != 2*a0 {
+		m = d*a - m
+		d = (n - m*m) / d
+		a = (a0 + m) / d
+		period = append(period, a)
+	}
+	return a0, period
+}
+
+// Pell returns the fundamental solution of x^2 - n*y^2 = 1, the least with y > 0, for n
+// not a square. It is the convergent just before the end of the first period of sqrt(n),
+// or of the second if the period is odd. The solution can be far larger than n, so it is
+// returned as big integers.
+func Pell(n int64) (x, y *big.Int, err error) {
+	a0, period := SqrtExpansion(n)
+	if period == nil {
+		return nil, nil, fmt.Errorf("pell: %d is a square", n)
+	}
+	last := len(period) - 1
+	if len(period)%2 == 1 {
+		last += len(period)
+	}
+	p0, q0 := big.NewInt(1), big.NewInt(0)
+	p1, q1 := big.NewInt(a0), big.NewInt(1)
+	for i := 0; i < last; i++ {
+		a := big.NewInt(period[i%len(period)])
+		p0, p1 = p1, new(big.Int).Add(new(big.Int).Mul(a, p1), p0)
+		q0, q1 = q1, new(big.Int).Add(new(big.Int).Mul(a, q1), q0)
+	}
+	return p1, q1, nil
+}
@@ -0,0 +1,160 @@
+package contfrac
+
+import (
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/intarith"
+	"github.com/johnkerl/goffl/pkg/intmod"
+)
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		num, den int64
+		want     []int64
+	}{
+		{415, 93, []int64{4, 2, 6, 7}},
+		{-415, 93, []int64{-5, 1, 1, 6, 7}},
+		{415, -93, []int64{-5, 1, 1, 6, 7}},
+		{3, 1, []int64{3}},
+		{0, 5, []int64{0}},
+		{1, 2, []int64{0, 2}},
+	}
+	for _, tt := range tests {
+		cf := Expand(tt.num, tt.den)
+		if !slices.Equal(cf, tt.want) {
+			t.Errorf("Expand(%d,%d) = %v, want %v", tt.num, tt.den, cf, tt.want)
+		}
+		conv := Convergents(cf)
+		last := conv[len(conv)-1]
+		if last.Num*tt.den != last.Den*tt.num || last.Den <= 0 {
+			t.Errorf("last convergent of %d/%d is %v", tt.num, tt.den, last)
+		}
+	}
+	conv := Convergents([]int64{4, 2, 6, 7})
+	want := []Rational{{4, 1}, {9, 2}, {58, 13}, {415, 93}}
+	if !slices.Equal(conv, want) {
+		t.Errorf("Convergents = %v, want %v", conv, want)
+	}
+}
+
+func TestBestApproximation(t *testing.T) {
+	// pi ~ 314159265358979/100000000000000: 22/7, 333/106, 355/113.
+	const num, den = 314159265358979, 100000000000000
+	tests := []struct {
+		maxDen int64
+		want   Rational
+	}{
+		{1, Rational{3, 1}}, {7, Rational{22, 7}}, {50, Rational{22, 7}}, {57, Rational{179, 57}},
+		{106, Rational{333, 106}}, {112, Rational{333, 106}}, {113, Rational{355, 113}},
+		{1000, Rational{355, 113}},
+	}
+	for _, tt := range tests {
+		if got := BestApproximation(num, den, tt.maxDen); got != tt.want {
+			t.Errorf("BestApproximation(pi, %d) = %v, want %v", tt.maxDen, got, tt.want)
+		}
+	}
+	// Brute force over small fractions.
+	for n := int64(-40); n <= 40; n++ {
+		for d := int64(1); d <= 17; d++ {
+			for maxDen := int64(1); maxDen <= 8; maxDen++ {
+				got := BestApproximation(n, d, maxDen)
+				for q := int64(1); q <= maxDen; q++ {
+					p := floorDiv(n*q, d)
+					for _, c := range []Rational{{p, q}, {p + 1, q}} {
+						if closer(c, got, n, d) {
+							t.Errorf("BestApproximation(%d/%d, %d) = %v but %v is closer", n, d, maxDen, got, c)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func TestRationalReconstruct(t *testing.T) {
+	const m = 1000003
+	for _, r := range []Rational{{1, 2}, {-3, 7}, {22, 7}, {0, 1}, {-500, 703}, {700, 701}} {
+		inv, _ := intarith.IntModRecip(r.Den, m)
+		x := intmod.New(intarith.MulMod(r.Num, inv, m), m)
+		got, err := RationalReconstruct(x)
+		if err != nil || got != r {
+			t.Errorf("RationalReconstruct(%v) = %v, %v; want %v", x.Residue, got, err, r)
+		}
+	}
+	if got, err := RationalReconstruct(intmod.New(6, 11)); err != nil || got != (Rational{1, 2}) {
+		t.Errorf("RationalReconstruct(6 mod 11) = %v, %v; want 1/2", got, err)
+	}
+	for _, m := range []int64{1, 2} {
+		if got, err := RationalReconstruct(intmod.New(0, m)); err != nil || got != (Rational{0, 1}) {
+			t.Errorf("RationalReconstruct(0 mod %d) = %v, %v; want 0/1", m, got, err)
+		}
+	}
+	if _, err := RationalReconstruct(intmod.New(1, 2)); err == nil || !strings.Contains(err.Error(), "modulus 2") {
+		t.Errorf("RationalReconstruct(1 mod 2) error = %v; want one about the modulus", err)
+	}
+	if _, err := RationalReconstructBounded(intmod.New(6, 11), 0, 1); err == nil {
+		t.Error("6 mod 11 has no a/b with a = 0, b = 1")
+	}
+	for _, b := range [][2]int64{{-1, 1}, {3, 0}, {3, -2}} {
+		if _, err := RationalReconstructBounded(intmod.New(6, 11), b[0], b[1]); err == nil {
+			t.Errorf("bounds %d, %d should be rejected", b[0], b[1])
+		}
+	}
+}
+
+func TestSqrtExpansion(t *testing.T) {
+	tests := []struct {
+		n, a0  int64
+		period []int64
+	}{
+		{2, 1, []int64{2}}, {3, 1, []int64{1, 2}}, {7, 2, []int64{1, 1, 1, 4}},
+		{13, 3, []int64{1, 1, 1, 1, 6}}, {16, 4, nil}, {0, 0, nil},
+	}
+	for _, tt := range tests {
+		a0, period := SqrtExpansion(tt.n)
+		if a0 != tt.a0 || !slices.Equal(period, tt.period) {
+			t.Errorf("SqrtExpansion(%d) = %d, %v; want %d, %v", tt.n, a0, period, tt.a0, tt.period)
+		}
+	}
+}
+
+func TestPell(t *testing.T) {
+	tests := []struct {
+		n    int64
+		x, y string
+	}{
+		{2, "3", "2"}, {13, "649", "180"}, {61, "1766319049", "226153980"},
+		{661, "16421658242965910275055840472270471049", "638728478116949861246791167518480580"},
+	}
+	for _, tt := range tests {
+		x, y, err := Pell(tt.n)
+		if err != nil || x.String() != tt.x || y.String() != tt.y {
+			t.Errorf("Pell(%d) = %v, %v, %v; want %s, %s", tt.n, x, y, err, tt.x, tt.y)
+		}
+	}
+	for n := int64(2); n < 200; n++ {
+		x, y, err := Pell(n)
+		if err != nil {
+			continue
+		}
+		lhs := new(big.Int).Mul(x, x)
+		lhs.Sub(lhs, new(big.Int).Mul(big.NewInt(n), new(big.Int).Mul(y, y)))
+		if lhs.Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("Pell(%d) = %v, %v does not solve x^2 - n y^2 = 1", n, x, y)
+		}
+	}
+	if _, _, err := Pell(49); err == nil {
+		t.Error("Pell(49) should fail")
+	}
+}
@@ -0,0 +1,163 @@
+// Package cunningham has precomputed factorizations of 2^n-1 and 2^n+1, complete for n up
+// to MinusOneComplete and PlusOneComplete respectively and partial up to MaxN. They are
+// assembled from an embedded table of the cyclotomic values Phi_d(2), since 2^n-1 is the
+// product of Phi_d(2) over d | n, and 2^n+1 the product over d | 2n with d not dividing
+// n. Past those bounds some Phi_d(2) in the table have a composite part gen.go could not
+// split; the lookups for every n needing one of those report false. About 60% of 2^n-1
+// and half of 2^n+1 for n up to MaxN are covered. Rerunning gen.go with -known on the
+// published Cunningham-project factorizations fills in the rest.
+package cunningham
+
+import (
+	_ "embed"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/johnkerl/goffl/pkg/factorization"
+)
+
+//go:generate go run gen.go
+
+// MaxN is the largest n the table reaches; past the bounds below, only some n up to it
+// are covered.
+const MaxN = 1024
+
+// MinusOneComplete and PlusOneComplete are the bounds up to which BigMinusOne and
+// BigPlusOne succeed for every n. Phi_277(2) and Phi_538(2) are the first entries left
+// incomplete.
+const (
+	MinusOneComplete = 276
+	PlusOneComplete  = 268
+)
+
+//go:embed cyclotomic2.txt
+var cyclotomicText string
+
+// cyclotomicEntry is the factorization of one Phi_d(2); complete is false if it has a
+// composite part that is not listed.
+type cyclotomicEntry struct {
+	factors  []*big.Int
+	mults    []int
+	complete bool
+}
+
+// table holds the entries for d = 1 .. 2*MaxN at index d.
+var table = sync.OnceValue(func() []cyclotomicEntry {
+	entries := make([]cyclotomicEntry, 2*MaxN+1)
+	for _, line := range strings.Split(cyclotomicText, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		d, err := strconv.Atoi(fields[0])
+		if err != nil || d < 1 || d > 2*MaxN {
+			panic(fmt.Sprintf("cunningham: bad table line %q", line))
+		}
+		entry := cyclotomicEntry{complete: true}
+		for _, field := range fields[1:] {
+			if field == "C" {
+				entry.complete = false
+				continue
+			}
+			base, exp, _ := strings.Cut(field, "^")
+			p, ok := new(big.Int).SetString(base, 10)
+			mult := 1
+			if exp != "" {
+				mult, err = strconv.Atoi(exp)
+			}
+			if !ok || err != nil {
+				panic(fmt.Sprintf("cunningham: bad factor %q for d = %d", field, d))
+			}
+			entry.factors = append(entry.factors, p)
+			entry.mults = append(entry.mults, mult)
+		}
+		entries[d] = entry
+	}
+	return entries
+})
+
+// product multiplies out the entries for the d with include(d), or reports false if
+// one of them is incomplete.
+func product(maxD int, include func(d int) bool) (*factorization.BigFactorization, bool) {
+	entries := table()
+	finfo := factorization.NewBig()
+	for d := 1; d <= maxD; d++ {
+		if !include(d) {
+			continue
+		}
+		entry := entries[d]
+		if !entry.complete {
+			return nil, false
+		}
+		for i, p := range entry.factors {
+			finfo.InsertFactor(p, entry.mults[i])
+		}
+	}
+	if finfo.NumDistinctFactors() == 0 {
+		finfo.InsertTrivialFactor(big.NewInt(1))
+	}
+	return finfo, true
+}
+
+// BigMinusOne returns the factorization of 2^n-1 for 1 <= n <= MaxN, or false if n is
+// out of range or the table lacks part of it, which does not happen for n up to
+// MinusOneComplete.
+func BigMinusOne(n int) (*factorization.BigFactorization, bool) {
+	if n < 1 || n > MaxN {
+		return nil, false
+	}
+	return product(n, func(d int) bool { return n%d == 0 })
+}
+
+// BigPlusOne returns the factorization of 2^n+1 for 1 <= n <= MaxN, or false if n is out
+// of range or the table lacks part of it, which does not happen for n up to
+// PlusOneComplete.
+func BigPlusOne(n int) (*factorization.BigFactorization, bool) {
+	if n < 1 || n > MaxN {
+		return nil, false
+	}
+	return product(2*n, func(d int) bool { return (2*n)%d == 0 && n%d != 0 })
+}
+
+// MinusOne is BigMinusOne for the n <= 63 where 2^n-1 fits in an int64.
+func MinusOne(n int) (*factorization.Factorization, bool) {
+	if n > 63 {
+		return nil, false
+	}
+	finfo, ok := BigMinusOne(n)
+	if !ok {
+		return nil, false
+	}
+	return finfo.Int64()
+}
+
+// PlusOne is BigPlusOne for the n <= 62 where 2^n+1 fits in an int64.
+func PlusOne(n int) (*factorization.Factorization, bool) {
+	if n > 62 {
+		return nil, false
+	}
+	finfo, ok := BigPlusOne(n)
+	if !ok {
+		return nil, false
+	}
+	return finfo.Int64()
+}
+
+// Lookup returns the factorization of m if m is 2^n-1 or 2^n+1 for some n >= 1 covered
+// by the table, as the totient of an irreducible F2Poly of degree n is.
+func Lookup(m int64) (*factorization.Factorization, bool) {
+	if m < 1 {
+		return nil, false
+	}
+	if u := uint64(m) + 1; u&(u-1) == 0 {
+		return MinusOne(bits.TrailingZeros64(u))
+	}
+	if u := uint64(m) - 1; m > 2 && u&(u-1) == 0 {
+		return PlusOne(bits.TrailingZeros64(u))
+	}
+	return nil, false
+}
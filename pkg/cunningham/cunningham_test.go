@@ -0,0 +1,134 @@
+package cunningham
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/intfactor"
+	"github.com/johnkerl/goffl/pkg/primes"
+)
+
+func TestTable(t *testing.T) {
+	one := big.NewInt(1)
+	complete := 0
+	for d, entry := range table()[1:] {
+		d++
+		// Phi_d(2) = prod over k | d of (2^k - 1)^mu(d/k).
+		num, den := big.NewInt(1), big.NewInt(1)
+		for k := 1; k <= d; k++ {
+			if d%k == 0 {
+				v := new(big.Int).Sub(new(big.Int).Lsh(one, uint(k)), one)
+				switch intfactor.Factor(int64(d / k)).Mobius() {
+				case 1:
+					num.Mul(num, v)
+				case -1:
+					den.Mul(den, v)
+				}
+			}
+		}
+		phi := num.Quo(num, den)
+		prod := big.NewInt(1)
+		for i, p := range entry.factors {
+			if !primes.IsPrimeBig(p) {
+				t.Errorf("Phi_%d(2): factor %v is not prime", d, p)
+			}
+			prod.Mul(prod, new(big.Int).Exp(p, big.NewInt(int64(entry.mults[i])), nil))
+		}
+		if entry.complete {
+			complete++
+			if prod.Cmp(phi) != 0 {
+				t.Errorf("Phi_%d(2): factors multiply to %v, want %v", d, prod, phi)
+			}
+		} else if new(big.Int).Mod(phi, prod).Sign() != 0 || prod.Cmp(phi) == 0 {
+			t.Errorf("Phi_%d(2): listed factors do not leave a cofactor", d)
+		}
+	}
+	// Everything through 2^64+1 must be there for the int64 lookups.
+	for d := 1; d <= 128; d++ {
+		if !table()[d].complete {
+			t.Errorf("Phi_%d(2) is incomplete", d)
+		}
+	}
+	t.Logf("%d of %d entries complete", complete, 2*MaxN)
+}
+
+func TestMinusOnePlusOne(t *testing.T) {
+	for n := 1; n <= 63; n++ {
+		finfo, ok := MinusOne(n)
+		if !ok || finfo.Unfactor() != int64(1<<n-1) {
+			t.Errorf("MinusOne(%d) = %v, %v", n, finfo, ok)
+		} else if n <= 40 && finfo.String() != intfactor.Factor(1<<n-1).String() {
+			t.Errorf("MinusOne(%d) = %v, want %v", n, finfo, intfactor.Factor(1<<n-1))
+		}
+	}
+	for n := 1; n <= 62; n++ {
+		finfo, ok := PlusOne(n)
+		if !ok || finfo.Unfactor() != int64(1<<n+1) {
+			t.Errorf("PlusOne(%d) = %v, %v", n, finfo, ok)
+		}
+	}
+	for _, n := range []int{0, 64, MaxN + 1} {
+		if _, ok := MinusOne(n); ok {
+			t.Errorf("MinusOne(%d) should be out of range", n)
+		}
+	}
+	if _, ok := BigMinusOne(MaxN + 1); ok {
+		t.Errorf("BigMinusOne(%d) should be out of range", MaxN+1)
+	}
+}
+
+func TestBig(t *testing.T) {
+	one := big.NewInt(1)
+	for n := 1; n <= MaxN; n++ {
+		pow := new(big.Int).Lsh(one, uint(n))
+		if finfo, ok := BigMinusOne(n); ok {
+			if want := new(big.Int).Sub(pow, one); finfo.Unfactor().Cmp(want) != 0 {
+				t.Errorf("BigMinusOne(%d) multiplies to %v", n, finfo.Unfactor())
+			}
+		} else if n <= MinusOneComplete {
+			t.Errorf("BigMinusOne(%d) not found, want every n up to %d", n, MinusOneComplete)
+		}
+		if finfo, ok := BigPlusOne(n); ok {
+			if want := new(big.Int).Add(pow, one); finfo.Unfactor().Cmp(want) != 0 {
+				t.Errorf("BigPlusOne(%d) multiplies to %v", n, finfo.Unfactor())
+			}
+		} else if n <= PlusOneComplete {
+			t.Errorf("BigPlusOne(%d) not found, want every n up to %d", n, PlusOneComplete)
+		}
+	}
+	// The bounds are tight, so that they move when the table grows.
+	if _, ok := BigMinusOne(MinusOneComplete + 1); ok {
+		t.Errorf("BigMinusOne(%d) found; raise MinusOneComplete", MinusOneComplete+1)
+	}
+	if _, ok := BigPlusOne(PlusOneComplete + 1); ok {
+		t.Errorf("BigPlusOne(%d) found; raise PlusOneComplete", PlusOneComplete+1)
+	}
+	// 2^127-1 is prime, and 2^128+1 = Phi_256(2) comes from trial division.
+	if finfo, ok := BigMinusOne(127); !ok || finfo.NumFactors() != 1 {
+		t.Errorf("BigMinusOne(127) = %v, %v; want one prime", finfo, ok)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		m    int64
+		want string
+		ok   bool
+	}{
+		{1, "1", true},
+		{3, "3", true},
+		{5, "5", true},
+		{255, "3 5 17", true},
+		{257, "257", true},
+		{1<<62 - 1, "3 715827883 2147483647", true},
+		{1<<62 + 1, "5 5581 8681 49477 384773", true},
+		{2, "", false},
+		{100, "", false},
+	}
+	for _, tt := range tests {
+		finfo, ok := Lookup(tt.m)
+		if ok != tt.ok || (ok && finfo.String() != tt.want) {
+			t.Errorf("Lookup(%d) = %v, %v; want %s, %v", tt.m, finfo, ok, tt.want, tt.ok)
+		}
+	}
+}
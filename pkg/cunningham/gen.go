@@ -0,0 +1,563 @@
+//go:build ignore
+
+// gen.go writes cyclotomic2.txt; run it with go generate. It factors each Phi_d(2) by
+// trial division over the primes 1 mod d, which with the largest prime of d are the only
+// ones that can divide it. For d = 4 mod 8 it first splits Phi_d(2) along the
+// Aurifeuillian factorization of 2^(d/2)+1. Composite parts left over go to the
+// elliptic curve method with growing bounds, and to intfactor.QuadraticSieve once they
+// are small enough, until a time budget per d runs out. What is still composite then is
+// written as C, so that the lookups needing it report not found.
+//
+// With -resume, the factors already in the output file are kept, complete entries are
+// copied, and only the incomplete ones are worked on further, so that the table can be
+// extended over several runs with larger budgets.
+//
+// With -known, primes from a file are divided out of every Phi_d(2) first. This is how
+// the published Cunningham-project factorizations get in: the file may be one of their
+// tables or any list of factors, since every prime written in decimal in it is used and
+// the rest of the text is skipped.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"math/bits"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/johnkerl/goffl/pkg/intfactor"
+	"github.com/johnkerl/goffl/pkg/primes"
+)
+
+// options bounds the work spent on each Phi_d(2).
+type options struct {
+	trialK int64
+	qsBits int
+	maxB1  int64
+	budget time.Duration
+}
+
+func main() {
+	maxD := flag.Int("max", 2048, "largest d to factor Phi_d(2) for")
+	fromD := flag.Int("from", 1, "smallest d to work on; others are copied with -resume")
+	toD := flag.Int("to", 2048, "largest d to work on; others are copied with -resume")
+	trialK := flag.Int64("trial", 1<<20, "trial-divide by k*d+1 for k up to this")
+	qsBits := flag.Int("qsbits", 220, "largest composite, in bits, to give QuadraticSieve")
+	maxB1 := flag.Int64("b1", 1000000, "largest elliptic curve stage-one bound")
+	budget := flag.Duration("budget", 10*time.Second, "time to spend on each Phi_d(2)")
+	resume := flag.Bool("resume", false, "start from the factors already in the output file")
+	knownFile := flag.String("known", "", "file of published prime factors to divide out first")
+	out := flag.String("o", "cyclotomic2.txt", "output file")
+	flag.Parse()
+	opts := options{trialK: *trialK, qsBits: *qsBits, maxB1: *maxB1, budget: *budget}
+
+	var imported []*big.Int
+	if *knownFile != "" {
+		var err error
+		if imported, err = readPrimes(*knownFile); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("read %d primes from %s", len(imported), *knownFile)
+	}
+	var old map[int]string
+	if *resume {
+		var err error
+		if old, err = readTable(*out); err != nil {
+			log.Fatal(err)
+		}
+	}
+	lines := make([]string, *maxD+1)
+	incomplete := 0
+	for d := 1; d <= *maxD; d++ {
+		line, ok := old[d]
+		switch {
+		case ok && !strings.HasSuffix(line, " C"):
+		case ok && (d < *fromD || d > *toD):
+			incomplete++
+		default:
+			// A resumed entry has had its trial division already.
+			known := imported
+			o := opts
+			if ok {
+				known, o.trialK = append(parseFactors(line), imported...), 0
+			}
+			start := time.Now()
+			if line, ok = factorPhi(d, known, o); !ok {
+				incomplete++
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				log.Printf("d=%d: %v, complete %v", d, elapsed.Round(time.Millisecond), ok)
+			}
+		}
+		lines[d] = line
+		// Write as we go, so that an interrupted run keeps its work.
+		if d%16 == 0 || d == *maxD {
+			if err := writeTable(*out, lines[1:d+1], old, *maxD); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	log.Printf("wrote %s: %d of %d incomplete", *out, incomplete, *maxD)
+}
+
+// readTable returns the lines of a table written by writeTable, by d.
+func readTable(name string) (map[int]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	rv := map[int]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		var d int
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := fmt.Sscan(line, &d); err != nil {
+			return nil, fmt.Errorf("%s: bad line %q", name, line)
+		}
+		rv[d] = line
+	}
+	return rv, nil
+}
+
+// readPrimes returns the distinct primes written in decimal in the named file, whatever
+// the text around them. Those that divide no Phi_d(2) are harmless: dividing them out
+// does nothing.
+func readPrimes(name string) ([]*big.Int, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var rv []*big.Int
+	for _, field := range strings.FieldsFunc(string(data), func(r rune) bool { return r < '0' || r > '9' }) {
+		p, ok := new(big.Int).SetString(field, 10)
+		if !ok || seen[p.String()] || !primes.IsPrimeBig(p) {
+			continue
+		}
+		seen[p.String()] = true
+		rv = append(rv, p)
+	}
+	return rv, nil
+}
+
+// parseFactors returns the distinct primes on a table line, and exits if one is not
+// prime.
+func parseFactors(line string) []*big.Int {
+	var rv []*big.Int
+	for _, field := range strings.Fields(line)[1:] {
+		base, _, _ := strings.Cut(field, "^")
+		if p, ok := new(big.Int).SetString(base, 10); ok {
+			if !primes.IsPrimeBig(p) {
+				log.Fatalf("listed factor %v is not prime: %q", p, line)
+			}
+			rv = append(rv, p)
+		}
+	}
+	return rv
+}
+
+// writeTable writes the lines done so far, followed by the old lines for the d not yet
+// reached, so that the file is whole after every write. It writes a temporary file and
+// renames it, so that an interrupted write leaves the previous one.
+func writeTable(name string, done []string, old map[int]string, maxD int) error {
+	f, err := os.Create(name + ".tmp")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "# Prime factors of the cyclotomic values Phi_d(2), written by gen.go. Each line")
+	fmt.Fprintln(w, "# is d and the primes, with p^e for a repeated one; a final C marks a composite")
+	fmt.Fprintln(w, "# cofactor not yet split, which leaves that Phi_d(2) incomplete.")
+	for _, line := range done {
+		fmt.Fprintln(w, line)
+	}
+	for d := len(done) + 1; d <= maxD; d++ {
+		if line, ok := old[d]; ok {
+			fmt.Fprintln(w, line)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+// cyclotomic2 returns Phi_d(2) = prod over k | d of (2^k - 1)^mu(d/k).
+func cyclotomic2(d int) *big.Int {
+	num, den := big.NewInt(1), big.NewInt(1)
+	one := big.NewInt(1)
+	for k := 1; k <= d; k++ {
+		if d%k != 0 {
+			continue
+		}
+		v := new(big.Int).Sub(new(big.Int).Lsh(one, uint(k)), one)
+		switch intfactor.Factor(int64(d / k)).Mobius() {
+		case 1:
+			num.Mul(num, v)
+		case -1:
+			den.Mul(den, v)
+		}
+	}
+	return num.Quo(num, den)
+}
+
+func pow2Mod(e, m uint64) uint64 {
+	r, b := uint64(1), 2%m
+	for ; e != 0; e >>= 1 {
+		if e&1 == 1 {
+			hi, lo := bits.Mul64(r, b)
+			r = bits.Rem64(hi, lo, m)
+		}
+		hi, lo := bits.Mul64(b, b)
+		b = bits.Rem64(hi, lo, m)
+	}
+	return r
+}
+
+// aurifeuillian returns the two parts of Phi_d(2) for d = 4m with m odd: since
+// 2^(2m)+1 = (2^m - 2^((m+1)/2) + 1)(2^m + 2^((m+1)/2) + 1), with the two coprime, n
+// splits as its gcds with them. For other d it returns n alone.
+func aurifeuillian(d int, n *big.Int) []*big.Int {
+	if d%8 != 4 {
+		return []*big.Int{n}
+	}
+	m := uint(d / 4)
+	one := big.NewInt(1)
+	l := new(big.Int).Lsh(one, m)
+	l.Sub(l, new(big.Int).Lsh(one, (m+1)/2))
+	l.Add(l, one)
+	g := new(big.Int).GCD(nil, nil, n, l)
+	return []*big.Int{g, new(big.Int).Quo(n, g)}
+}
+
+func factorPhi(d int, known []*big.Int, opts options) (string, bool) {
+	n := cyclotomic2(d)
+	line := fmt.Sprint(d)
+	found := map[string]int{}
+	var order []*big.Int
+	divideOut := func(p *big.Int) {
+		q, r := new(big.Int), new(big.Int)
+		for {
+			q.QuoRem(n, p, r)
+			if r.Sign() != 0 {
+				return
+			}
+			n.Set(q)
+			if found[p.String()] == 0 {
+				order = append(order, new(big.Int).Set(p))
+			}
+			found[p.String()]++
+		}
+	}
+	for _, p := range known {
+		divideOut(p)
+	}
+	// The intrinsic factor: the largest prime of d can divide Phi_d(2) once.
+	fd := intfactor.Factor(int64(d))
+	if k := fd.NumDistinctFactors(); k > 0 {
+		r, _ := fd.Get(k - 1)
+		divideOut(big.NewInt(r))
+	}
+	// Other prime factors p have 2 of order d mod p, so p = 1 mod d, and mod 2d for odd p.
+	step := int64(d)
+	if d%2 == 1 {
+		step = 2 * int64(d)
+	}
+	for p := step + 1; p <= step*opts.trialK && n.BitLen() > 1; p += step {
+		if pow2Mod(uint64(d), uint64(p)) == 1 {
+			divideOut(big.NewInt(p))
+		}
+	}
+
+	deadline := time.Now().Add(opts.budget)
+	rng := rand.New(rand.NewSource(int64(d)))
+	work := aurifeuillian(d, new(big.Int).Set(n))
+	complete := true
+	for len(work) > 0 {
+		c := work[len(work)-1]
+		work = work[:len(work)-1]
+		if c.Cmp(big.NewInt(1)) == 0 {
+			continue
+		}
+		// Earlier primes may already have been divided out of n; what is left of c is
+		// coprime to them.
+		c = new(big.Int).GCD(nil, nil, c, n)
+		switch {
+		case c.Cmp(big.NewInt(1)) == 0:
+		case primes.IsPrimeBig(c):
+			divideOut(c)
+		default:
+			f := split(c, deadline, opts, rng)
+			if f == nil {
+				complete = false
+				continue
+			}
+			work = append(work, f, new(big.Int).Quo(c, f))
+		}
+	}
+	slices.SortFunc(order, (*big.Int).Cmp)
+	for _, p := range order {
+		if e := found[p.String()]; e > 1 {
+			line += fmt.Sprintf(" %v^%d", p, e)
+		} else {
+			line += " " + p.String()
+		}
+	}
+	if !complete {
+		line += " C"
+	}
+	return line, complete
+}
+
+// ecmSchedule is the usual sequence of stage-one bounds and curve counts for finding
+// factors of about 15, 20, 25, 30 and 35 digits.
+var ecmSchedule = []struct {
+	b1     int64
+	curves int
+}{
+	{2000, 25}, {11000, 90}, {50000, 300}, {250000, 700}, {1000000, 1800},
+}
+
+// qsDirectBits is the size up to which the quadratic sieve takes well under a minute,
+// so that split tries it before any elliptic curves.
+const qsDirectBits = 200
+
+// split returns a proper factor of the composite c, or nil if none turned up before the
+// deadline. It runs the elliptic curve method level by level, and the quadratic sieve
+// once c is small enough: at once up to qsDirectBits, and up to opts.qsBits after
+// factors below 20 digits have been ruled out.
+func split(c *big.Int, deadline time.Time, opts options, rng *rand.Rand) *big.Int {
+	if r := new(big.Int).Sqrt(c); new(big.Int).Mul(r, r).Cmp(c) == 0 {
+		return r
+	}
+	qsLevel := 2
+	if c.BitLen() <= qsDirectBits {
+		qsLevel = 0
+	}
+	for level, s := range ecmSchedule {
+		if level == qsLevel && c.BitLen() <= opts.qsBits {
+			if f, err := intfactor.QuadraticSieve(c); err == nil {
+				return f
+			}
+		}
+		if s.b1 > opts.maxB1 {
+			break
+		}
+		for range s.curves {
+			if time.Now().After(deadline) {
+				return nil
+			}
+			if f := ecmCurve(c, s.b1, 100*s.b1, rng); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// ecmArith does arithmetic mod n, reusing its scratch space.
+type ecmArith struct {
+	n, q, t *big.Int
+}
+
+// mul sets z = x*y mod n, up to sign: values stay below n in absolute value.
+func (e *ecmArith) mul(z, x, y *big.Int) {
+	e.t.Mul(x, y)
+	e.q.QuoRem(e.t, e.n, z)
+}
+
+// ecmPoint is a point on a Montgomery curve B y^2 = x^3 + A x^2 + x in projective x and
+// z coordinates, with y not tracked.
+type ecmPoint struct{ x, z *big.Int }
+
+func newPoint() ecmPoint { return ecmPoint{new(big.Int), new(big.Int)} }
+
+// dbl sets r = 2p, with a24 = (A+2)/4.
+func (e *ecmArith) dbl(r, p ecmPoint, a24 *big.Int) {
+	s, d, t := new(big.Int).Add(p.x, p.z), new(big.Int).Sub(p.x, p.z), new(big.Int)
+	e.mul(s, s, s)
+	e.mul(d, d, d)
+	t.Sub(s, d)
+	e.mul(r.x, s, d)
+	e.mul(s, a24, t)
+	s.Add(s, d)
+	e.mul(r.z, t, s)
+}
+
+// add sets r = p + q given diff = p - q.
+func (e *ecmArith) add(r, p, q, diff ecmPoint) {
+	u, v := new(big.Int).Sub(p.x, p.z), new(big.Int).Add(q.x, q.z)
+	e.mul(u, u, v)
+	v.Add(p.x, p.z)
+	w := new(big.Int).Sub(q.x, q.z)
+	e.mul(v, v, w)
+	w.Add(u, v)
+	u.Sub(u, v)
+	e.mul(w, w, w)
+	e.mul(u, u, u)
+	dx, dz := new(big.Int).Set(diff.x), new(big.Int).Set(diff.z)
+	e.mul(r.x, dz, w)
+	e.mul(r.z, dx, u)
+}
+
+// ladder returns k*p by the Montgomery ladder.
+func (e *ecmArith) ladder(p ecmPoint, k uint64, a24 *big.Int) ecmPoint {
+	r0 := ecmPoint{new(big.Int).Set(p.x), new(big.Int).Set(p.z)}
+	if k == 1 {
+		return r0
+	}
+	r1 := newPoint()
+	e.dbl(r1, p, a24)
+	for i := bits.Len64(k) - 2; i >= 0; i-- {
+		if k>>i&1 == 1 {
+			e.add(r0, r0, r1, p)
+			e.dbl(r1, r1, a24)
+		} else {
+			e.add(r1, r1, r0, p)
+			e.dbl(r0, r0, a24)
+		}
+	}
+	return r0
+}
+
+// ecmCurve runs one curve of the elliptic curve method on n, with Suyama's parametrization
+// from a random sigma, through stage one to b1 and the standard continuation to b2. It
+// returns a proper factor, or nil.
+func ecmCurve(n *big.Int, b1, b2 int64, rng *rand.Rand) *big.Int {
+	e := &ecmArith{n: n, q: new(big.Int), t: new(big.Int)}
+	one := big.NewInt(1)
+	proper := func(g *big.Int) *big.Int {
+		g.GCD(nil, nil, g.Abs(g), n)
+		if g.Cmp(one) != 0 && g.Cmp(n) != 0 {
+			return g
+		}
+		return nil
+	}
+
+	sigma := big.NewInt(6 + rng.Int63n(1<<40))
+	u := new(big.Int).Mul(sigma, sigma)
+	u.Sub(u, big.NewInt(5))
+	v := new(big.Int).Lsh(sigma, 2)
+	u3 := new(big.Int).Exp(u, big.NewInt(3), n)
+	p := ecmPoint{u3, new(big.Int).Exp(v, big.NewInt(3), n)}
+	// a24 = (v-u)^3 (3u+v) / (16 u^3 v).
+	num := new(big.Int).Sub(v, u)
+	num.Exp(num, big.NewInt(3), n)
+	e.mul(num, num, new(big.Int).Add(new(big.Int).Mul(u, big.NewInt(3)), v))
+	den := new(big.Int).Mul(u3, v)
+	den.Lsh(den, 4).Mod(den, n)
+	inv := new(big.Int).ModInverse(den, n)
+	if inv == nil {
+		return proper(den)
+	}
+	a24 := new(big.Int)
+	e.mul(a24, num, inv)
+
+	// Stage one: multiply by every prime power up to b1.
+	for _, q := range primes.Sieve(b1) {
+		qe := q
+		for qe <= b1/q {
+			qe *= q
+		}
+		p = e.ladder(p, uint64(qe), a24)
+	}
+	if f := proper(new(big.Int).Set(p.z)); f != nil {
+		return f
+	}
+
+	// Stage two: for each prime b1 < l <= b2, written l = m*D +- j, the point l*P is the
+	// identity mod a factor exactly when x(mD P) z(jP) - x(jP) z(mD P) vanishes mod it.
+	const D = 2310
+	isPrime := primeBits(b2 + D)
+	baby := make([]ecmPoint, D/2)
+	baby[1] = p
+	p2 := newPoint()
+	e.dbl(p2, p, a24)
+	baby[3] = newPoint()
+	e.add(baby[3], p2, p, p)
+	for j := 5; j < D/2; j += 2 {
+		baby[j] = newPoint()
+		e.add(baby[j], baby[j-2], p2, baby[j-4])
+	}
+	var js []int
+	for j := 1; j < D/2; j += 2 {
+		if gcdInt(j, D) == 1 {
+			js = append(js, j)
+		}
+	}
+	step := e.ladder(p, D, a24)
+	m := b1/D + 1
+	cur := e.ladder(p, uint64(m*D), a24)
+	var prev ecmPoint
+	if m > 1 {
+		prev = e.ladder(p, uint64((m-1)*D), a24)
+	}
+	acc, t1, t2 := big.NewInt(1), new(big.Int), new(big.Int)
+	for ; m*D-D/2 <= b2; m++ {
+		for _, j := range js {
+			if isPrime(m*D-int64(j)) || isPrime(m*D+int64(j)) {
+				e.mul(t1, cur.x, baby[j].z)
+				e.mul(t2, baby[j].x, cur.z)
+				t1.Sub(t1, t2)
+				e.mul(acc, acc, t1)
+			}
+		}
+		next := newPoint()
+		if m == 1 {
+			// The difference would be the point at infinity; double instead.
+			e.dbl(next, cur, a24)
+		} else {
+			e.add(next, cur, step, prev)
+		}
+		prev, cur = cur, next
+	}
+	return proper(acc)
+}
+
+func gcdInt(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// primeCache keeps the last odd-prime bitmap made by primeBits.
+var primeCache struct {
+	limit int64
+	odd   []uint64
+}
+
+// primeBits returns a primality test for 0 <= l <= limit, from a sieve of the odd numbers.
+func primeBits(limit int64) func(int64) bool {
+	if primeCache.limit < limit {
+		// composite has bit i set for the odd 2i+1 that are not prime.
+		composite := make([]uint64, limit/128+1)
+		composite[0] |= 1
+		for i := int64(1); (2*i+1)*(2*i+1) <= limit; i++ {
+			if composite[i/64]>>(i%64)&1 == 0 {
+				p := 2*i + 1
+				for k := p * p / 2; k <= limit/2; k += p {
+					composite[k/64] |= 1 << (k % 64)
+				}
+			}
+		}
+		primeCache.limit, primeCache.odd = limit, composite
+	}
+	odd := primeCache.odd
+	return func(l int64) bool {
+		if l < 3 {
+			return l == 2
+		}
+		return l%2 == 1 && odd[l/2/64]>>(l/2%64)&1 == 0
+	}
+}
@@ -0,0 +1,190 @@
+// Package dlog provides discrete logarithms in Z/nZ: baby-step giant-step and Pollard rho
+// for subgroups of prime order, combined by Pohlig-Hellman.
+package dlog
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/johnkerl/goffl/pkg/factorization"
+	"github.com/johnkerl/goffl/pkg/intarith"
+	"github.com/johnkerl/goffl/pkg/intfactor"
+	"github.com/johnkerl/goffl/pkg/intmod"
+	"github.com/johnkerl/goffl/pkg/order"
+)
+
+// bsgsMaxOrder is the largest prime-order subgroup PohligHellman solves by baby-step
+// giant-step; larger ones use Pollard rho, whose memory does not grow with the order.
+const bsgsMaxOrder = 1 << 32
+
+// rhoMaxAttempts bounds the random restarts Pollard rho makes before concluding that h is
+// not in the subgroup.
+const rhoMaxAttempts = 32
+
+// Log returns the least x >= 0 with g^x = h, or an error if h is not in the subgroup
+// generated by g. g must be a unit; the modulus need not be prime. The order of g comes
+// from order.ModOrderIntMod and is factored with intfactor.Factor.
+func Log(g, h *intmod.IntMod) (int64, error) {
+	n, err := order.ModOrderIntMod(g)
+	if err != nil {
+		return 0, fmt.Errorf("dlog: %w", err)
+	}
+	return PohligHellman(g, h, intfactor.Factor(n))
+}
+
+// PohligHellman returns the least x >= 0 with g^x = h, where nfactors is the
+// factorization of the order of g, or an error if h is not in the subgroup generated by
+// g. The logarithm is found mod each prime power p^e dividing the order, one base-p digit
+// at a time in the subgroup of order p, and the results are combined with intmod.CRT.
+func PohligHellman(g, h *intmod.IntMod, nfactors *factorization.Factorization) (int64, error) {
+	checkModuli(g, h)
+	n := nfactors.Unfactor()
+	if n < 1 {
+		return 0, fmt.Errorf("dlog: order %d is not positive", n)
+	}
+	if !pow(h, n).Equal(pow(g, 0)) {
+		return 0, notInSubgroup(g, h)
+	}
+
+	residues := make([]*intmod.IntMod, nfactors.NumDistinctFactors())
+	for i := range residues {
+		p, e := nfactors.Get(i)
+		pe := int64(1)
+		for j := 0; j < e; j++ {
+			pe *= p
+		}
+		// g_i and h_i live in the subgroup of order p^e, and gamma generates its
+		// subgroup of order p.
+		gi, hi := pow(g, n/pe), pow(h, n/pe)
+		gamma := pow(gi, pe/p)
+		x, pk := int64(0), int64(1)
+		for k := 0; k < e; k++ {
+			// (g_i^-x * h_i)^(p^(e-1-k)) = gamma^(k-th digit).
+			hk := pow(pow(gi, pe-x).Mul(hi), pe/p/pk)
+			d, err := primeOrderLog(gamma, hk, p)
+			if err != nil {
+				return 0, err
+			}
+			x += d * pk
+			pk *= p
+		}
+		residues[i] = intmod.New(x, pe)
+	}
+	x, err := intmod.CRT(residues)
+	if err != nil {
+		return 0, fmt.Errorf("dlog: %w", err)
+	}
+	if !pow(g, x.Residue).Equal(h) {
+		return 0, notInSubgroup(g, h)
+	}
+	return x.Residue, nil
+}
+
+// primeOrderLog returns log_g h where g has prime order p.
+func primeOrderLog(g, h *intmod.IntMod, p int64) (int64, error) {
+	if p <= bsgsMaxOrder {
+		return BabyStepGiantStep(g, h, p)
+	}
+	return PollardRho(g, h, p)
+}
+
+// BabyStepGiantStep returns the least x in 0..n-1 with g^x = h, where g^n = 1, or an
+// error if there is none. It stores about sqrt(n) powers of g.
+func BabyStepGiantStep(g, h *intmod.IntMod, n int64) (int64, error) {
+	checkModuli(g, h)
+	m := int64(math.Ceil(math.Sqrt(float64(n))))
+	for m*m < n {
+		m++
+	}
+	baby := make(map[int64]int64, m)
+	gj := pow(g, 0)
+	for j := int64(0); j < m; j++ {
+		if _, ok := baby[gj.Residue]; !ok {
+			baby[gj.Residue] = j
+		}
+		gj = gj.Mul(g)
+	}
+	// g^-m = g^(n-m) since g^n = 1.
+	giant := pow(g, ((n-m)%n+n)%n)
+	gamma := h
+	for i := int64(0); i < m; i++ {
+		if j, ok := baby[gamma.Residue]; ok {
+			if x := i*m + j; x < n {
+				return x, nil
+			}
+		}
+		gamma = gamma.Mul(giant)
+	}
+	return 0, notInSubgroup(g, h)
+}
+
+// PollardRho returns x in 0..p-1 with g^x = h, where g has prime order p, or an error if
+// h is not in the subgroup generated by g. It walks x -> x*g, x^2 or x*h, chosen by the
+// residue mod 3, from a random g^a h^b, and solves the collision Floyd's cycle finding
+// turns up. Its memory is constant.
+func PollardRho(g, h *intmod.IntMod, p int64) (int64, error) {
+	checkModuli(g, h)
+	if p == 1 {
+		return 0, nil
+	}
+	if !pow(h, p).Equal(pow(g, 0)) {
+		return 0, notInSubgroup(g, h)
+	}
+	for attempt := 0; attempt < rhoMaxAttempts; attempt++ {
+		a0, b0 := rand.Int63n(p), rand.Int63n(p)
+		x := rhoPoint{pow(g, a0).Mul(pow(h, b0)), a0, b0}
+		y := x
+		for {
+			x = x.step(g, h, p)
+			y = y.step(g, h, p).step(g, h, p)
+			if x.v.Equal(y.v) {
+				break
+			}
+		}
+		// g^xa h^xb = g^ya h^yb, so (xb - yb) log h = ya - xa mod p.
+		db := (x.b - y.b + p) % p
+		if db == 0 {
+			continue
+		}
+		inv, err := intarith.IntModExp(db, p-2, p)
+		if err != nil {
+			return 0, fmt.Errorf("dlog: %w", err)
+		}
+		return intarith.MulMod((y.a-x.a+p)%p, inv, p), nil
+	}
+	return 0, notInSubgroup(g, h)
+}
+
+// rhoPoint is v = g^a h^b.
+type rhoPoint struct {
+	v    *intmod.IntMod
+	a, b int64
+}
+
+func (r rhoPoint) step(g, h *intmod.IntMod, p int64) rhoPoint {
+	switch r.v.Residue % 3 {
+	case 0:
+		return rhoPoint{r.v.Mul(g), (r.a + 1) % p, r.b}
+	case 1:
+		return rhoPoint{r.v.Mul(r.v), intarith.MulMod(r.a, 2, p), intarith.MulMod(r.b, 2, p)}
+	default:
+		return rhoPoint{r.v.Mul(h), r.a, (r.b + 1) % p}
+	}
+}
+
+// pow returns a^e for e >= 0.
+func pow(a *intmod.IntMod, e int64) *intmod.IntMod {
+	r, _ := intarith.IntModExp(a.Residue, e, a.Modulus())
+	return intmod.New(r, a.Modulus())
+}
+
+func checkModuli(g, h *intmod.IntMod) {
+	if g.Modulus() != h.Modulus() {
+		panic("modulus mismatch")
+	}
+}
+
+func notInSubgroup(g, h *intmod.IntMod) error {
+	return fmt.Errorf("dlog: %d is not a power of %d mod %d", h.Residue, g.Residue, g.Modulus())
+}
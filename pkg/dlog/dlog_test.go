@@ -0,0 +1,71 @@
+package dlog
+
+import (
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/intarith"
+	"github.com/johnkerl/goffl/pkg/intfactor"
+	"github.com/johnkerl/goffl/pkg/intmod"
+)
+
+func TestLogBrute(t *testing.T) {
+	for m := int64(2); m <= 60; m++ {
+		for _, g := range intmod.UnitsForModulus(m) {
+			want := make(map[int64]int64)
+			x := int64(0)
+			for gx := intmod.New(1, m); ; gx = gx.Mul(g) {
+				if _, ok := want[gx.Residue]; ok {
+					break
+				}
+				want[gx.Residue] = x
+				x++
+			}
+			for h := int64(0); h < m; h++ {
+				got, err := Log(g, intmod.New(h, m))
+				w, ok := want[h]
+				if ok && (err != nil || got != w) {
+					t.Errorf("log_%d %d mod %d = %d, %v; want %d", g.Residue, h, m, got, err, w)
+				}
+				if !ok && err == nil {
+					t.Errorf("log_%d %d mod %d = %d; want error", g.Residue, h, m, got)
+				}
+			}
+		}
+	}
+}
+
+func TestLogLarge(t *testing.T) {
+	// 2^61-1 is prime; 37 generates its unit group, whose order 2^61-2 has prime factors
+	// up to 1321.
+	const p = 1<<61 - 1
+	g := intmod.New(37, p)
+	nfactors := intfactor.Factor(p - 1)
+	for _, x := range []int64{0, 1, 123456789, p - 2} {
+		r, _ := intarith.IntModExp(37, x, p)
+		got, err := PohligHellman(g, intmod.New(r, p), nfactors)
+		if err != nil || got != x {
+			t.Errorf("log_37 37^%d = %d, %v", x, got, err)
+		}
+	}
+}
+
+func TestPollardRho(t *testing.T) {
+	// p = 2q+1 with q prime, so the nonzero squares mod p form a subgroup of order q.
+	const q, p = 1000151, 2000303
+	g := intmod.New(4, p)
+	for _, x := range []int64{0, 1, 777, q - 1} {
+		r, _ := intarith.IntModExp(4, x, p)
+		got, err := PollardRho(g, intmod.New(r, p), q)
+		if err != nil || got != x {
+			t.Errorf("rho: log_4 4^%d = %d, %v", x, got, err)
+		}
+		got, err = BabyStepGiantStep(g, intmod.New(r, p), q)
+		if err != nil || got != x {
+			t.Errorf("bsgs: log_4 4^%d = %d, %v", x, got, err)
+		}
+	}
+	// p-1 is not a square mod p = 3 mod 4, so not in the subgroup of squares.
+	if _, err := PollardRho(g, intmod.New(p-1, p), q); err == nil {
+		t.Error("rho: -1 should not be a power of 4")
+	}
+}
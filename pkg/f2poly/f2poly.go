@@ -21,17 +21,27 @@ func bitDegree(bits uint64) int {
 	return d
 }
 
+// bitMul returns the low 64 bits of the carry-less product.
 func bitMul(this, that uint64) uint64 {
-	a, b, c := this, that, uint64(0)
-	ashift := a
-	degThat := bitDegree(that)
-	for j := 0; j <= degThat; j++ {
-		if ((b >> j) & 1) == 1 {
-			c ^= ashift
+	_, lo := bitarith.ClMul(this, that)
+	return lo
+}
+
+// reduceWide returns (hi:lo) mod m for the 128-bit polynomial hi:lo and nonzero m.
+func reduceWide(hi, lo, m uint64) uint64 {
+	d := bitDegree(m)
+	// d <= 63 so every set bit of hi is at least one place above the leading bit of m.
+	for hi != 0 {
+		shift := 64 + bitarith.MsbPos(hi) - d
+		if shift >= 64 {
+			hi ^= m << (shift - 64)
+		} else {
+			hi ^= m >> (64 - shift)
+			lo ^= m << shift
 		}
-		ashift <<= 1
 	}
-	return c
+	_, rem, _ := iquotAndRem(lo, m)
+	return rem
 }
 
 func iquotAndRem(this, that uint64) (quot, rem uint64, err error) {
@@ -119,10 +129,48 @@ func (f *F2Poly) Sub(other *F2Poly) *F2Poly {
 
 func (f *F2Poly) Neg() *F2Poly { return &F2Poly{Bits: f.Bits} }
 
+// Mul returns the product. Only products of degree up to 63 are representable; past that
+// the result keeps just the low 64 coefficients. Use MulChecked, MulWide or MulMod when
+// the degree can be larger.
 func (f *F2Poly) Mul(other *F2Poly) *F2Poly {
 	return &F2Poly{Bits: bitMul(f.Bits, other.Bits)}
 }
 
+// MulChecked returns the product, or an error if it has degree above 63.
+func (f *F2Poly) MulChecked(other *F2Poly) (*F2Poly, error) {
+	hi, lo := bitarith.ClMul(f.Bits, other.Bits)
+	if hi != 0 {
+		return nil, fmt.Errorf("product of degree %d overflows 64 bits", f.Degree()+other.Degree())
+	}
+	return &F2Poly{Bits: lo}, nil
+}
+
+// MulWide returns the full product, of degree up to 126, as its high part (coefficients of
+// x^64 and up, shifted down by 64) and its low part.
+func (f *F2Poly) MulWide(other *F2Poly) (hi, lo *F2Poly) {
+	h, l := bitarith.ClMul(f.Bits, other.Bits)
+	return &F2Poly{Bits: h}, &F2Poly{Bits: l}
+}
+
+// MulMod returns f * other mod m. The product is reduced at full width, so it never
+// overflows.
+func (f *F2Poly) MulMod(other, m *F2Poly) *F2Poly {
+	if m == nil || m.Bits == 0 {
+		panic("f2poly: division by zero")
+	}
+	hi, lo := bitarith.ClMul(f.Bits, other.Bits)
+	return &F2Poly{Bits: reduceWide(hi, lo, m.Bits)}
+}
+
+// SquareMod returns f^2 mod m.
+func (f *F2Poly) SquareMod(m *F2Poly) *F2Poly {
+	if m == nil || m.Bits == 0 {
+		panic("f2poly: division by zero")
+	}
+	hi, lo := bitarith.ClSquare(f.Bits)
+	return &F2Poly{Bits: reduceWide(hi, lo, m.Bits)}
+}
+
 func (f *F2Poly) QuoRem(other *F2Poly) (q, r *F2Poly, err error) {
 	quot, rem, err := iquotAndRem(f.Bits, other.Bits)
 	if err != nil {
@@ -160,6 +208,10 @@ func (f *F2Poly) Pow(e int) (*F2Poly, error) {
 	if e < 0 {
 		return nil, fmt.Errorf("negative exponents disallowed")
 	}
+	if deg := f.Degree(); deg > 0 && e > 63/deg {
+		return nil, fmt.Errorf("power of degree-%d polynomial overflows 64 bits", deg)
+	}
+	// With the degree checked above, no partial product below passes degree 63.
 	rv := &F2Poly{Bits: 1}
 	xp := &F2Poly{Bits: f.Bits}
 	for e != 0 {
@@ -167,7 +219,9 @@ func (f *F2Poly) Pow(e int) (*F2Poly, error) {
 			rv = rv.Mul(xp)
 		}
 		e >>= 1
-		xp = xp.Mul(xp)
+		if e != 0 {
+			xp = xp.Mul(xp)
+		}
 	}
 	return rv, nil
 }
@@ -192,8 +246,16 @@ func (f *F2Poly) Gcd(other *F2Poly) *F2Poly {
 	return &F2Poly{Bits: d}
 }
 
+// Lcm returns the least common multiple. Like Mul, it is truncated to the low 64
+// coefficients when its degree is above 63; use LcmChecked when that can happen.
 func (f *F2Poly) Lcm(other *F2Poly) *F2Poly {
-	return f.Mul(other).Quo(f.Gcd(other))
+	// Divide first so the intermediate is no bigger than the result.
+	return f.Quo(f.Gcd(other)).Mul(other)
+}
+
+// LcmChecked returns the least common multiple, or an error if it has degree above 63.
+func (f *F2Poly) LcmChecked(other *F2Poly) (*F2Poly, error) {
+	return f.Quo(f.Gcd(other)).MulChecked(other)
 }
 
 func (f *F2Poly) ExtGcd(other *F2Poly) (g, s, t *F2Poly) {
@@ -220,11 +282,7 @@ func (f *F2Poly) ExtGcd(other *F2Poly) (g, s, t *F2Poly) {
 
 func (f *F2Poly) Deriv() *F2Poly {
 	// Odd-bit mask (0x55...): in GF(2), derivative drops even powers and shifts.
-	mask := uint64(0x55555555)
-	for mask < f.Bits {
-		mask = (mask << 32) | 0x55555555
-	}
-	bits := (f.Bits >> 1) & mask
+	bits := (f.Bits >> 1) & 0x5555555555555555
 	return &F2Poly{Bits: bits}
 }
 
@@ -1,6 +1,7 @@
 package f2poly_test
 
 import (
+	"github.com/johnkerl/goffl/pkg/bitarith"
 	"github.com/johnkerl/goffl/pkg/f2poly"
 	"github.com/johnkerl/goffl/pkg/f2polyfactor"
 	"testing"
@@ -37,6 +38,20 @@ func TestArithmetic(t *testing.T) {
 	}
 }
 
+func TestDeriv(t *testing.T) {
+	// d/dx keeps the odd powers, shifted down one; this once looped forever at degree 62+.
+	tests := []struct{ in, want uint64 }{
+		{0x13, 0x1},
+		{1<<63 | 1<<62 | 1<<7 | 1<<2 | 1<<1, 1<<62 | 1<<6 | 1},
+		{1<<62 | 1<<60 | 1, 0},
+	}
+	for _, tt := range tests {
+		if got := f2poly.New(tt.in).Deriv().Bits; got != tt.want {
+			t.Errorf("Deriv(0x%x) = 0x%x, want 0x%x", tt.in, got, tt.want)
+		}
+	}
+}
+
 func TestGcd(t *testing.T) {
 	a := f2poly.New(0x13)
 	b := f2poly.New(0x0B)
@@ -64,3 +79,139 @@ func TestFactor(t *testing.T) {
 		t.Error("factor(0x7) should have at least one factor")
 	}
 }
+
+func TestFactorUnfactor(t *testing.T) {
+	for bits := uint64(2); bits < 1<<10; bits++ {
+		f := f2poly.New(bits)
+		finfo := f2polyfactor.Factor(f)
+		prod := f2poly.New(1)
+		for i := 0; i < finfo.NumDistinctFactors(); i++ {
+			g, m := finfo.Get(i)
+			if g.Degree() > 1 && !f2polyfactor.Irr(g) {
+				t.Errorf("factor(0x%x): factor 0x%x is reducible", bits, g.Bits)
+			}
+			for k := 0; k < m; k++ {
+				prod = prod.Mul(g)
+			}
+		}
+		if !prod.Equal(f) {
+			t.Errorf("factor(0x%x): product of factors is 0x%x", bits, prod.Bits)
+		}
+	}
+}
+
+// mulModNaive reduces f*g mod m one product bit at a time, Horner style.
+func mulModNaive(f, g, m *f2poly.F2Poly) *f2poly.F2Poly {
+	hi, lo := f.MulWide(g)
+	r := f2poly.New(0)
+	for j := 127; j >= 0; j-- {
+		bit := lo.Get(j % 64)
+		if j >= 64 {
+			bit = hi.Get(j - 64)
+		}
+		r = f2poly.New(r.Bits<<1 | uint64(bit)).Mod(m)
+	}
+	return r
+}
+
+func TestMulWide(t *testing.T) {
+	a := f2poly.New(1<<40 | 1)
+	b := f2poly.New(1<<40 | 1<<3)
+	hi, lo := a.MulWide(b)
+	if hi.Bits != 1<<16 || lo.Bits != 1<<43|1<<40|1<<3 {
+		t.Errorf("MulWide = %x:%x", hi.Bits, lo.Bits)
+	}
+	if got := a.Mul(b); got.Bits != lo.Bits {
+		t.Errorf("Mul = %x, want low part %x", got.Bits, lo.Bits)
+	}
+	if _, err := a.MulChecked(b); err == nil {
+		t.Error("MulChecked of degree-40 polynomials should overflow")
+	}
+	if _, err := a.LcmChecked(b); err == nil {
+		t.Error("LcmChecked of degree-40 polynomials should overflow")
+	}
+	if p, err := a.MulChecked(f2poly.New(0x3)); err != nil || p.Degree() != 41 {
+		t.Errorf("MulChecked: %v", err)
+	}
+}
+
+func TestMulMod(t *testing.T) {
+	moduli := []uint64{0x3, 0x13, 1<<41 | 1<<3 | 1, 1<<63 | 1<<4 | 1<<3 | 1<<1 | 1}
+	for _, mBits := range moduli {
+		m := f2poly.New(mBits)
+		for i := 0; i < 200; i++ {
+			f := f2poly.Random(m.Degree() - 1 + i%2)
+			g := f2poly.Random(m.Degree() - 1)
+			if got, want := f.MulMod(g, m), mulModNaive(f, g, m); !got.Equal(want) {
+				t.Fatalf("(%x * %x) mod %x = %x, want %x", f.Bits, g.Bits, mBits, got.Bits, want.Bits)
+			}
+			if got, want := f.SquareMod(m), mulModNaive(f, f, m); !got.Equal(want) {
+				t.Fatalf("%x^2 mod %x = %x, want %x", f.Bits, mBits, got.Bits, want.Bits)
+			}
+		}
+	}
+}
+
+func TestPowOverflow(t *testing.T) {
+	if p, err := f2poly.New(0x3).Pow(63); err != nil || p.Degree() != 63 {
+		t.Errorf("(x+1)^63: %v", err)
+	}
+	if _, err := f2poly.New(0x3).Pow(64); err == nil {
+		t.Error("(x+1)^64 should overflow")
+	}
+	if p, err := f2poly.New(1).Pow(1000); err != nil || !p.IsOne() {
+		t.Errorf("1^1000: %v", err)
+	}
+}
+
+func TestSparseIrr(t *testing.T) {
+	for degree := 1; degree <= 10; degree++ {
+		// Brute force: the lowest irreducible overall, and the lowest of least weight.
+		// Like the searches, skip x itself, the one irreducible without a constant term.
+		var lowest, sparsest *f2poly.F2Poly
+		counts := map[int]int{}
+		for bits := uint64(1) << degree; bits < 1<<(degree+1); bits++ {
+			f := f2poly.New(bits)
+			if bits&1 == 0 || !f2polyfactor.Irr(f) {
+				continue
+			}
+			weight := bitarith.Ones(bits)
+			counts[weight]++
+			if lowest == nil {
+				lowest = f
+			}
+			if sparsest == nil || weight < bitarith.Ones(sparsest.Bits) {
+				sparsest = f
+			}
+		}
+		if got, _ := f2polyfactor.LowestIrr(degree); !got.Equal(lowest) {
+			t.Errorf("LowestIrr(%d) = %v, want %v", degree, got, lowest)
+		}
+		if got, _ := f2polyfactor.SparsestIrr(degree); !got.Equal(sparsest) {
+			t.Errorf("SparsestIrr(%d) = %v, want %v", degree, got, sparsest)
+		}
+		for weight := 2; weight <= degree+1; weight++ {
+			n := 0
+			for f := range f2polyfactor.IrrOfWeight(degree, weight) {
+				if f.Degree() != degree || bitarith.Ones(f.Bits) != weight {
+					t.Fatalf("IrrOfWeight(%d, %d) yielded %v", degree, weight, f)
+				}
+				n++
+			}
+			if n != counts[weight] {
+				t.Errorf("IrrOfWeight(%d, %d) yielded %d, want %d", degree, weight, n, counts[weight])
+			}
+		}
+	}
+	if got, _ := f2polyfactor.SparsestIrr(8); got.Bits != 0x11b {
+		t.Errorf("SparsestIrr(8) = %v, want 11b", got)
+	}
+	if got, err := f2polyfactor.LowestIrr(63); err != nil || got.Bits != 1<<63|3 {
+		t.Errorf("LowestIrr(63) = %v, %v; want x^63+x+1", got, err)
+	}
+	for _, degree := range []int{0, 64} {
+		if _, err := f2polyfactor.LowestIrr(degree); err == nil {
+			t.Errorf("LowestIrr(%d) should fail", degree)
+		}
+	}
+}
@@ -3,7 +3,9 @@ package f2polyfactor
 
 import (
 	"fmt"
+	"iter"
 
+	"github.com/johnkerl/goffl/pkg/bitarith"
 	"github.com/johnkerl/goffl/pkg/bitmatrix"
 	"github.com/johnkerl/goffl/pkg/bitvector"
 	"github.com/johnkerl/goffl/pkg/f2poly"
@@ -161,7 +163,7 @@ func berlekamp(f *f2poly.F2Poly, finfo *PolyFactorization, recurse bool) {
 		for i := 0; i < n; i++ {
 			bi.Rows[n-1-i].Set(n-1-j, x2i.Get(i))
 		}
-		x2i = x2i.Mul(x2modf).Mod(f)
+		x2i = x2i.MulMod(x2modf, f)
 	}
 	for i := 0; i < n; i++ {
 		bi.Rows[i].ToggleElement(i)
@@ -188,8 +190,8 @@ func berlekamp(f *f2poly.F2Poly, finfo *PolyFactorization, recurse bool) {
 		h := f2polyFromVector(nullspaceBasis.Row(row), n)
 		hc := h.Add(oneF2)
 
-		check1 := h.Mul(h).Mod(f)
-		check2 := hc.Mul(hc).Mod(f)
+		check1 := h.SquareMod(f)
+		check2 := hc.SquareMod(f)
 		if !h.Equal(check1) || !hc.Equal(check2) {
 			panic("coding error detected: h^2 check")
 		}
@@ -216,11 +218,13 @@ func berlekamp(f *f2poly.F2Poly, finfo *PolyFactorization, recurse bool) {
 	panic("coding error detected: berlekamp")
 }
 
+// f2polyFromVector reads a kernel vector of the Berlekamp matrix, whose column n-1-j holds
+// the coefficient of x^j, back into a polynomial.
 func f2polyFromVector(v *bitvector.BitVector, n int) *f2poly.F2Poly {
 	f := &f2poly.F2Poly{Bits: 0}
 	for i := 0; i < n; i++ {
 		val, _ := v.Get(i)
-		f.Set(i, val)
+		f.Set(n-1-i, val)
 	}
 	return f
 }
@@ -237,18 +241,70 @@ func Irr(f *f2poly.F2Poly) bool {
 	return finfo.NumFactors() == 1
 }
 
+// LowestIrr returns the numerically lowest irreducible of the given degree, leaving out x
+// itself. It takes each odd weight in turn through bitarith.FixedWeight, stopping each at
+// the lowest irreducible found so far, so that the candidates tried are the odd-weight
+// ones below the answer, as in a plain upward count.
 func LowestIrr(degree int) (*f2poly.F2Poly, error) {
-	if degree < 1 {
-		return nil, fmt.Errorf("lowest_irr: degree must be positive; got %d", degree)
+	if degree < 1 || degree > 63 {
+		return nil, fmt.Errorf("lowest_irr: degree must be 1..63; got %d", degree)
 	}
-	rv := &f2poly.F2Poly{Bits: (1 << degree) | 1}
-	for rv.Degree() == degree {
-		if Irr(rv) {
-			return rv, nil
+	var best *f2poly.F2Poly
+	for weight := 2; weight <= degree+1; weight++ {
+		// Above degree 1, x+1 divides anything of even weight.
+		if degree > 1 && weight%2 == 0 {
+			continue
+		}
+		for middle := range bitarith.FixedWeight(degree-1, weight-2) {
+			f := &f2poly.F2Poly{Bits: (1 << degree) | middle<<1 | 1}
+			if best != nil && !f.Less(best) {
+				break
+			}
+			if Irr(f) {
+				best = f
+				break
+			}
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("lowest_irr: coding error detected")
+	}
+	return best, nil
+}
+
+// IrrOfWeight yields the irreducibles of the given degree with exactly weight nonzero
+// coefficients, in increasing order, leaving out x itself. Above degree 1 only odd
+// weights have any, since x+1 divides the others; even weights yield nothing without
+// searching.
+func IrrOfWeight(degree, weight int) iter.Seq[*f2poly.F2Poly] {
+	return func(yield func(*f2poly.F2Poly) bool) {
+		if degree < 1 || degree > 63 || weight < 2 || (degree > 1 && weight%2 == 0) {
+			return
+		}
+		// The leading and constant coefficients are always set; the rest are chosen
+		// from the degree-1 coefficients in between.
+		for middle := range bitarith.FixedWeight(degree-1, weight-2) {
+			f := &f2poly.F2Poly{Bits: (1 << degree) | middle<<1 | 1}
+			if Irr(f) && !yield(f) {
+				return
+			}
+		}
+	}
+}
+
+// SparsestIrr returns the lowest irreducible of the given degree among those with the
+// fewest nonzero coefficients: a trinomial when there is one, else a pentanomial, and so
+// on.
+func SparsestIrr(degree int) (*f2poly.F2Poly, error) {
+	if degree < 1 || degree > 63 {
+		return nil, fmt.Errorf("sparsest_irr: degree must be 1..63; got %d", degree)
+	}
+	for weight := 2; weight <= degree+1; weight++ {
+		for f := range IrrOfWeight(degree, weight) {
+			return f, nil
 		}
-		rv.Bits += 2
 	}
-	return nil, fmt.Errorf("lowest_irr: coding error detected")
+	return nil, fmt.Errorf("sparsest_irr: coding error detected")
 }
 
 func RandomIrr(degree int) (*f2poly.F2Poly, error) {
@@ -47,7 +47,7 @@ func (a *F2PolyMod) Neg() *F2PolyMod {
 }
 
 func (a *F2PolyMod) Mul(other *F2PolyMod) *F2PolyMod {
-	r := a.Residue.Mul(other.Residue).Mod(a.modulus)
+	r := a.Residue.MulMod(other.Residue, a.modulus)
 	return &F2PolyMod{Residue: r, modulus: a.modulus}
 }
 
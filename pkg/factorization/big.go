@@ -0,0 +1,68 @@
+package factorization
+
+import (
+	"math/big"
+)
+
+type bigArith struct{}
+
+func (bigArith) FromInt64(n int64) *big.Int { return big.NewInt(n) }
+func (bigArith) Cmp(a, b *big.Int) int      { return a.Cmp(b) }
+func (bigArith) Mul(a, b *big.Int) *big.Int { return new(big.Int).Mul(a, b) }
+func (bigArith) Quo(a, b *big.Int) *big.Int { return new(big.Int).Quo(a, b) }
+func (bigArith) Clone(a *big.Int) *big.Int  { return new(big.Int).Set(a) }
+func (bigArith) String(a *big.Int) string   { return a.String() }
+
+// BigFactorization is Factorization for integers that do not fit in an int64: a trivial
+// factor and a sorted list of (factor, multiplicity). Factors are copied on the way in and
+// out, so callers may reuse their big.Ints.
+type BigFactorization struct {
+	factorList[*big.Int, bigArith]
+}
+
+func NewBig() *BigFactorization {
+	return &BigFactorization{}
+}
+
+// ToBig returns f as a BigFactorization.
+func (f *Factorization) ToBig() *BigFactorization {
+	rv := NewBig()
+	if t, ok := f.TrivialFactor(); ok {
+		rv.InsertTrivialFactor(big.NewInt(t))
+	}
+	for _, p := range f.factors {
+		rv.InsertFactor(big.NewInt(p.factor), p.mult)
+	}
+	return rv
+}
+
+// Int64 returns f as a Factorization, or false if the trivial factor or some prime does
+// not fit in an int64. The product itself may still overflow.
+func (f *BigFactorization) Int64() (*Factorization, bool) {
+	rv := New()
+	if f.hasTrivial {
+		if !f.trivialFactor.IsInt64() {
+			return nil, false
+		}
+		t := f.trivialFactor.Int64()
+		rv.InsertTrivialFactor(&t)
+	}
+	for _, p := range f.factors {
+		if !p.factor.IsInt64() {
+			return nil, false
+		}
+		rv.InsertFactor(p.factor.Int64(), p.mult)
+	}
+	return rv, true
+}
+
+func (f *BigFactorization) InsertTrivialFactor(n *big.Int) {
+	if n == nil {
+		return
+	}
+	f.insertTrivialFactor(n)
+}
+
+func (f *BigFactorization) Merge(other *BigFactorization) {
+	f.merge(&other.factorList)
+}
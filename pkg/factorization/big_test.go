@@ -0,0 +1,56 @@
+package factorization_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/factorization"
+	"github.com/johnkerl/goffl/pkg/intfactor"
+)
+
+// The int64 and big factorizations share their code; check they agree.
+func TestBigMatchesInt64(t *testing.T) {
+	for n := int64(-60); n <= 400; n++ {
+		finfo := intfactor.Factor(n)
+		bfinfo := finfo.ToBig()
+		if got, want := bfinfo.String(), finfo.String(); got != want {
+			t.Fatalf("%d: String %q, want %q", n, got, want)
+		}
+		if got := bfinfo.Unfactor(); !got.IsInt64() || got.Int64() != n {
+			t.Errorf("%d: Unfactor = %v", n, got)
+		}
+		if n == 0 {
+			continue
+		}
+		if got, want := fmt.Sprint(bfinfo.AllDivisors()), fmt.Sprint(finfo.AllDivisors()); got != want {
+			t.Errorf("%d: AllDivisors %s, want %s", n, got, want)
+		}
+		if got, want := fmt.Sprint(bfinfo.MaximalProperDivisors()), fmt.Sprint(finfo.MaximalProperDivisors()); got != want {
+			t.Errorf("%d: MaximalProperDivisors %s, want %s", n, got, want)
+		}
+		back, ok := bfinfo.Int64()
+		if !ok || back.String() != finfo.String() {
+			t.Errorf("%d: Int64 = %v, %v", n, back, ok)
+		}
+	}
+
+	// Merge and ExpAll, and that callers' big.Ints are copied on the way in and out.
+	a, b := factorization.NewBig(), factorization.NewBig()
+	p := big.NewInt(3)
+	a.InsertFactor(p, 2)
+	p.SetInt64(5)
+	b.InsertFactor(p, 1)
+	b.InsertFactor(big.NewInt(3), 1)
+	b.InsertTrivialFactor(big.NewInt(-1))
+	a.Merge(b)
+	a.ExpAll(2)
+	if got := a.String(); got != "1 3^6 5^2" {
+		t.Errorf("Merge and ExpAll gave %s, want 1 3^6 5^2", got)
+	}
+	q, _ := a.Get(0)
+	q.SetInt64(7)
+	if got := a.String(); got != "1 3^6 5^2" {
+		t.Errorf("changing Get's result changed the factorization to %s", got)
+	}
+}
@@ -7,29 +7,63 @@ import (
 	"strings"
 )
 
-// Factorization stores a trivial factor and list of (factor, multiplicity) for integers.
-type Factorization struct {
-	trivialFactor *int64
-	factors       []struct {
-		factor int64
-		mult   int
+// arith is the integer arithmetic the factorization code needs, implemented by the
+// zero-size int64Arith and bigArith. It mirrors part of intarith.Arith, which this
+// package cannot use since intarith imports it. Results never alias arguments.
+type arith[T any] interface {
+	FromInt64(n int64) T
+	Cmp(a, b T) int
+	Mul(a, b T) T
+	Quo(a, b T) T
+	// Clone returns a copy of a that the caller may keep.
+	Clone(a T) T
+	String(a T) string
+}
+
+type int64Arith struct{}
+
+func (int64Arith) FromInt64(n int64) int64 { return n }
+func (int64Arith) Mul(a, b int64) int64    { return a * b }
+func (int64Arith) Quo(a, b int64) int64    { return a / b }
+func (int64Arith) Clone(a int64) int64     { return a }
+func (int64Arith) String(a int64) string   { return fmt.Sprint(a) }
+
+func (int64Arith) Cmp(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
 	}
+	return 0
 }
 
-func New() *Factorization {
-	return &Factorization{factors: nil}
+type factor[T any] struct {
+	factor T
+	mult   int
+}
+
+// factorList is the code shared by Factorization and BigFactorization: a trivial factor
+// and a list of (factor, multiplicity) sorted by factor. Values are cloned on the way in
+// and out.
+type factorList[T any, A arith[T]] struct {
+	trivialFactor T
+	hasTrivial    bool
+	factors       []factor[T]
 }
 
-func (f *Factorization) TrivialFactor() (int64, bool) {
-	if f.trivialFactor == nil {
-		return 0, false
+func (f *factorList[T, A]) TrivialFactor() (T, bool) {
+	var ar A
+	if !f.hasTrivial {
+		var zero T
+		return zero, false
 	}
-	return *f.trivialFactor, true
+	return ar.Clone(f.trivialFactor), true
 }
 
-func (f *Factorization) NumDistinctFactors() int { return len(f.factors) }
+func (f *factorList[T, A]) NumDistinctFactors() int { return len(f.factors) }
 
-func (f *Factorization) NumFactors() int {
+func (f *factorList[T, A]) NumFactors() int {
 	n := 0
 	for _, p := range f.factors {
 		n += p.mult
@@ -37,175 +71,187 @@ func (f *Factorization) NumFactors() int {
 	return n
 }
 
-func (f *Factorization) Get(i int) (factor int64, mult int) {
-	return f.factors[i].factor, f.factors[i].mult
+func (f *factorList[T, A]) Get(i int) (factor T, mult int) {
+	var ar A
+	return ar.Clone(f.factors[i].factor), f.factors[i].mult
 }
 
-func (f *Factorization) InsertTrivialFactor(n *int64) {
-	if n == nil {
-		return
-	}
-	if f.trivialFactor != nil {
-		*f.trivialFactor *= *n
+func (f *factorList[T, A]) insertTrivialFactor(n T) {
+	var ar A
+	if f.hasTrivial {
+		f.trivialFactor = ar.Mul(f.trivialFactor, n)
 	} else {
-		p := new(int64)
-		*p = *n
-		f.trivialFactor = p
+		f.trivialFactor, f.hasTrivial = ar.Clone(n), true
 	}
 }
 
-func (f *Factorization) InsertFactor(newFactor int64, newMult int) {
+func (f *factorList[T, A]) InsertFactor(newFactor T, newMult int) {
+	var ar A
 	if newMult <= 0 {
 		return
 	}
-	for i := range f.factors {
-		if f.factors[i].factor == newFactor {
-			f.factors[i].mult += newMult
-			return
-		}
-		if newFactor < f.factors[i].factor {
-			f.factors = append(f.factors, struct {
-				factor int64
-				mult   int
-			}{})
-			copy(f.factors[i+1:], f.factors[i:])
-			f.factors[i] = struct {
-				factor int64
-				mult   int
-			}{newFactor, newMult}
-			return
-		}
+	i := sort.Search(len(f.factors), func(i int) bool { return ar.Cmp(f.factors[i].factor, newFactor) >= 0 })
+	if i < len(f.factors) && ar.Cmp(f.factors[i].factor, newFactor) == 0 {
+		f.factors[i].mult += newMult
+		return
 	}
-	f.factors = append(f.factors, struct {
-		factor int64
-		mult   int
-	}{newFactor, newMult})
+	f.factors = append(f.factors, factor[T]{})
+	copy(f.factors[i+1:], f.factors[i:])
+	f.factors[i] = factor[T]{ar.Clone(newFactor), newMult}
 }
 
-func (f *Factorization) Merge(other *Factorization) {
-	if t, ok := other.TrivialFactor(); ok {
-		f.InsertTrivialFactor(&t)
+func (f *factorList[T, A]) merge(other *factorList[T, A]) {
+	if other.hasTrivial {
+		f.insertTrivialFactor(other.trivialFactor)
 	}
-	for i := 0; i < other.NumDistinctFactors(); i++ {
-		p, m := other.Get(i)
-		f.InsertFactor(p, m)
+	for _, p := range other.factors {
+		f.InsertFactor(p.factor, p.mult)
 	}
 }
 
-func (f *Factorization) ExpAll(e int) {
-	if f.trivialFactor != nil {
-		exp := int64(1)
-		for i := 0; i < e; i++ {
-			exp *= *f.trivialFactor
-		}
-		*f.trivialFactor = exp
+func (f *factorList[T, A]) ExpAll(e int) {
+	if f.hasTrivial {
+		f.trivialFactor = pow[T, A](f.trivialFactor, e)
 	}
 	for i := range f.factors {
 		f.factors[i].mult *= e
 	}
 }
 
-func (f *Factorization) NumDivisors() int {
+func (f *factorList[T, A]) NumDivisors() int {
 	ndf := f.NumDistinctFactors()
 	if ndf <= 0 {
-		if f.trivialFactor == nil {
+		if !f.hasTrivial {
 			panic("num_divisors: no factors have been inserted")
 		}
 	}
 	rv := 1
-	for i := 0; i < ndf; i++ {
-		_, m := f.Get(i)
-		rv *= m + 1
+	for _, p := range f.factors {
+		rv *= p.mult + 1
 	}
 	return rv
 }
 
-func (f *Factorization) KthDivisor(k int) int64 {
+func (f *factorList[T, A]) KthDivisor(k int) T {
+	var ar A
 	ndf := f.NumDistinctFactors()
 	if ndf <= 0 {
-		if f.trivialFactor != nil {
-			return 1
+		if f.hasTrivial {
+			return ar.FromInt64(1)
 		}
 		panic("kth_divisor: no factors have been inserted")
 	}
-	rv := int64(1)
-	for i := 0; i < ndf; i++ {
-		p, m := f.Get(i)
-		base := m + 1
+	rv := ar.FromInt64(1)
+	for _, p := range f.factors {
+		base := p.mult + 1
 		power := k % base
 		k = k / base
 		for j := 0; j < power; j++ {
-			rv *= p
+			rv = ar.Mul(rv, p.factor)
 		}
 	}
 	return rv
 }
 
-func (f *Factorization) AllDivisors() []int64 {
+func (f *factorList[T, A]) AllDivisors() []T {
+	var ar A
 	ndf := f.NumDistinctFactors()
-	if ndf <= 0 && f.trivialFactor == nil {
+	if ndf <= 0 && !f.hasTrivial {
 		panic("all_divisors: no factors have been inserted")
 	}
 	nd := f.NumDivisors()
-	out := make([]int64, nd)
+	out := make([]T, nd)
 	for k := 0; k < nd; k++ {
 		out[k] = f.KthDivisor(k)
 	}
-	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	sort.Slice(out, func(i, j int) bool { return ar.Cmp(out[i], out[j]) < 0 })
 	return out
 }
 
-func (f *Factorization) MaximalProperDivisors() []int64 {
+func (f *factorList[T, A]) MaximalProperDivisors() []T {
+	var ar A
 	ndf := f.NumDistinctFactors()
 	if ndf <= 0 {
-		if f.trivialFactor == nil {
+		if !f.hasTrivial {
 			panic("maximal_proper_divisors: no factors have been inserted")
 		}
 		return nil
 	}
 	n := f.Unfactor()
-	out := make([]int64, ndf)
-	for k := 0; k < ndf; k++ {
-		p, _ := f.Get(k)
-		out[k] = n / p
+	out := make([]T, ndf)
+	for k, p := range f.factors {
+		out[k] = ar.Quo(n, p.factor)
 	}
-	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	sort.Slice(out, func(i, j int) bool { return ar.Cmp(out[i], out[j]) < 0 })
 	return out
 }
 
-func (f *Factorization) Unfactor() int64 {
+func (f *factorList[T, A]) Unfactor() T {
+	var ar A
 	ndf := f.NumDistinctFactors()
 	if ndf <= 0 {
-		if f.trivialFactor == nil {
+		if !f.hasTrivial {
 			panic("unfactor: no factors have been inserted")
 		}
-		return *f.trivialFactor
+		return ar.Clone(f.trivialFactor)
 	}
-	rv := int64(1)
-	if f.trivialFactor != nil {
-		rv = *f.trivialFactor
+	rv := ar.FromInt64(1)
+	if f.hasTrivial {
+		rv = ar.Clone(f.trivialFactor)
 	}
-	for i := 0; i < ndf; i++ {
-		p, e := f.Get(i)
-		for j := 0; j < e; j++ {
-			rv *= p
-		}
+	for _, p := range f.factors {
+		rv = ar.Mul(rv, pow[T, A](p.factor, p.mult))
 	}
 	return rv
 }
 
-func (f *Factorization) String() string {
+func (f *factorList[T, A]) String() string {
+	var ar A
 	var parts []string
-	if f.trivialFactor != nil {
-		parts = append(parts, fmt.Sprint(*f.trivialFactor))
-	}
-	for i := 0; i < f.NumDistinctFactors(); i++ {
-		p, m := f.Get(i)
-		s := fmt.Sprint(p)
-		if m != 1 {
-			s += fmt.Sprintf("^%d", m)
+	if f.hasTrivial {
+		parts = append(parts, ar.String(f.trivialFactor))
+	}
+	for _, p := range f.factors {
+		s := ar.String(p.factor)
+		if p.mult != 1 {
+			s += fmt.Sprintf("^%d", p.mult)
 		}
 		parts = append(parts, s)
 	}
 	return strings.Join(parts, " ")
 }
+
+// pow returns b^e by repeated squaring, or 1 for e <= 0; int64 results wrap on overflow.
+func pow[T any, A arith[T]](b T, e int) T {
+	var ar A
+	rv := ar.FromInt64(1)
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			rv = ar.Mul(rv, b)
+		}
+		if e > 1 {
+			b = ar.Mul(b, b)
+		}
+	}
+	return rv
+}
+
+// Factorization stores a trivial factor and list of (factor, multiplicity) for integers.
+type Factorization struct {
+	factorList[int64, int64Arith]
+}
+
+func New() *Factorization {
+	return &Factorization{}
+}
+
+func (f *Factorization) InsertTrivialFactor(n *int64) {
+	if n == nil {
+		return
+	}
+	f.insertTrivialFactor(*n)
+}
+
+func (f *Factorization) Merge(other *Factorization) {
+	f.merge(&other.factorList)
+}
@@ -0,0 +1,138 @@
+package factorization
+
+// Arithmetic functions of the integer n that f factors, computed from its primes and
+// multiplicities without further factoring. The trivial factor only contributes its sign
+// or, for n = 0, makes the result 0 where noted. Results are int64 and, like Unfactor,
+// wrap silently on overflow.
+
+// EulerPhi returns phi(|n|), the number of 1 <= a <= |n| coprime to n: the product of
+// p^(e-1) * (p-1) over the prime powers p^e of n. It is 0 for n = 0 and 1 for n = 1.
+func (f *Factorization) EulerPhi() int64 {
+	return f.JordanTotient(1)
+}
+
+// JordanTotient returns J_k(|n|), the number of k-tuples of 1..|n| whose gcd with n is 1:
+// the product of p^(k(e-1)) * (p^k - 1). J_1 is EulerPhi. It is 0 for n = 0. It panics
+// if k < 0, and wraps once p^(ke) passes int64 for a prime power p^e of n.
+func (f *Factorization) JordanTotient(k int) int64 {
+	if k < 0 {
+		panic("jordan_totient: k must be >= 0")
+	}
+	if f.isZero() {
+		return 0
+	}
+	rv := int64(1)
+	for _, p := range f.factors {
+		pk := ipow(p.factor, k)
+		rv *= ipow(pk, p.mult-1) * (pk - 1)
+	}
+	return rv
+}
+
+// CarmichaelLambda returns lambda(|n|), the exponent of the unit group mod n: the lcm of
+// lambda(p^e), which is p^(e-1) * (p-1) for odd p and 1, 2, 2^(e-2) for 2, 4, 2^e with
+// e >= 3. It is 0 for n = 0 and 1 for n = 1.
+func (f *Factorization) CarmichaelLambda() int64 {
+	if f.isZero() {
+		return 0
+	}
+	rv := int64(1)
+	for _, p := range f.factors {
+		var lambda int64
+		switch {
+		case p.factor == 2 && p.mult >= 3:
+			lambda = ipow(2, p.mult-2)
+		case p.factor == 2:
+			lambda = int64(p.mult)
+		default:
+			lambda = ipow(p.factor, p.mult-1) * (p.factor - 1)
+		}
+		rv = rv / gcd(rv, lambda) * lambda
+	}
+	return rv
+}
+
+// Mobius returns mu(n): 0 if a square divides n, else (-1)^k for n with k distinct prime
+// factors. It is 0 for n = 0.
+func (f *Factorization) Mobius() int {
+	if f.isZero() {
+		return 0
+	}
+	rv := 1
+	for _, p := range f.factors {
+		if p.mult > 1 {
+			return 0
+		}
+		rv = -rv
+	}
+	return rv
+}
+
+// Liouville returns lambda(n) = (-1)^Omega(n), where Omega counts prime factors with
+// multiplicity. It is 0 for n = 0.
+func (f *Factorization) Liouville() int {
+	if f.isZero() {
+		return 0
+	}
+	if f.NumFactors()%2 == 1 {
+		return -1
+	}
+	return 1
+}
+
+// Sigma returns sigma_k(|n|), the sum of the k-th powers of the positive divisors of n:
+// the product of 1 + p^k + p^(2k) + ... + p^(ek). Sigma(0) is NumDivisors and Sigma(1)
+// the sum of divisors. It is 0 for n = 0. It panics if k < 0, and wraps once the result
+// passes int64, which for k >= 3 happens already at modest n.
+func (f *Factorization) Sigma(k int) int64 {
+	if k < 0 {
+		panic("sigma: k must be >= 0")
+	}
+	if f.isZero() {
+		return 0
+	}
+	rv := int64(1)
+	for _, p := range f.factors {
+		pk := ipow(p.factor, k)
+		sum, term := int64(1), int64(1)
+		for j := 0; j < p.mult; j++ {
+			term *= pk
+			sum += term
+		}
+		rv *= sum
+	}
+	return rv
+}
+
+// Radical returns rad(|n|), the product of the distinct primes dividing n. It is 0 for
+// n = 0 and 1 for n = 1.
+func (f *Factorization) Radical() int64 {
+	if f.isZero() {
+		return 0
+	}
+	rv := int64(1)
+	for _, p := range f.factors {
+		rv *= p.factor
+	}
+	return rv
+}
+
+func (f *Factorization) isZero() bool {
+	return f.hasTrivial && f.trivialFactor == 0
+}
+
+// ipow returns b^e for e >= 0, wrapping on overflow.
+func ipow(b int64, e int) int64 {
+	rv := int64(1)
+	for ; e > 0; e-- {
+		rv *= b
+	}
+	return rv
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,96 @@
+package factorization_test
+
+import (
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/intarith"
+	"github.com/johnkerl/goffl/pkg/intfactor"
+)
+
+func TestMultiplicative(t *testing.T) {
+	for n := int64(1); n <= 500; n++ {
+		finfo := intfactor.Factor(n)
+		var phi, j2, sigma0, sigma1, sigma2 int64
+		lambda := int64(1)
+		for a := int64(1); a <= n; a++ {
+			if intarith.Gcd(a, n) == 1 {
+				phi++
+				// lambda is the least exponent killing every unit.
+				for {
+					if r, _ := intarith.IntModExp(a, lambda, n); r == 1%n {
+						break
+					}
+					lambda++
+				}
+			}
+			for b := int64(1); b <= n && a <= 50; b++ {
+				if intarith.Gcd(intarith.Gcd(a, b), n) == 1 {
+					j2++
+				}
+			}
+			if n%a == 0 {
+				sigma0++
+				sigma1 += a
+				sigma2 += a * a
+			}
+		}
+		if got := finfo.EulerPhi(); got != phi {
+			t.Errorf("EulerPhi(%d) = %d, want %d", n, got, phi)
+		}
+		if got := finfo.CarmichaelLambda(); got != lambda {
+			t.Errorf("CarmichaelLambda(%d) = %d, want %d", n, got, lambda)
+		}
+		if got := finfo.JordanTotient(2); n <= 50 && got != j2 {
+			t.Errorf("JordanTotient(%d, 2) = %d, want %d", n, got, j2)
+		}
+		if got := finfo.Sigma(0); got != sigma0 || got != int64(finfo.NumDivisors()) {
+			t.Errorf("Sigma(%d, 0) = %d, want %d", n, got, sigma0)
+		}
+		if got := finfo.Sigma(1); got != sigma1 {
+			t.Errorf("Sigma(%d, 1) = %d, want %d", n, got, sigma1)
+		}
+		if got := finfo.Sigma(2); got != sigma2 {
+			t.Errorf("Sigma(%d, 2) = %d, want %d", n, got, sigma2)
+		}
+	}
+}
+
+func TestMobiusLiouvilleRadical(t *testing.T) {
+	tests := []struct {
+		n       int64
+		mu, lio int
+		radical int64
+	}{
+		{1, 1, 1, 1}, {2, -1, -1, 2}, {4, 0, 1, 2}, {6, 1, 1, 6}, {12, 0, -1, 6},
+		{30, -1, -1, 30}, {72, 0, -1, 6}, {-30, -1, -1, 30}, {0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		finfo := intfactor.Factor(tt.n)
+		if got := finfo.Mobius(); got != tt.mu {
+			t.Errorf("Mobius(%d) = %d, want %d", tt.n, got, tt.mu)
+		}
+		if got := finfo.Liouville(); got != tt.lio {
+			t.Errorf("Liouville(%d) = %d, want %d", tt.n, got, tt.lio)
+		}
+		if got := finfo.Radical(); got != tt.radical {
+			t.Errorf("Radical(%d) = %d, want %d", tt.n, got, tt.radical)
+		}
+	}
+}
+
+func TestNegativeK(t *testing.T) {
+	finfo := intfactor.Factor(12)
+	for name, fn := range map[string]func(int) int64{
+		"JordanTotient": finfo.JordanTotient,
+		"Sigma":         finfo.Sigma,
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s(-1) should panic", name)
+				}
+			}()
+			fn(-1)
+		}()
+	}
+}
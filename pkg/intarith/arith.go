@@ -0,0 +1,99 @@
+package intarith
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// Arith is integer arithmetic on values of type T, so that number-theoretic algorithms
+// can be written once and run on either the int64 fast path (Int64Arith) or on big
+// integers (BigArith). Results never alias arguments.
+type Arith[T any] interface {
+	FromInt64(n int64) T
+	Sign(a T) int
+	Cmp(a, b T) int
+	Add(a, b T) T
+	Sub(a, b T) T
+	Mul(a, b T) T
+	// Quo returns a/b truncated toward zero.
+	Quo(a, b T) T
+	// Mod returns a mod m in 0..|m|-1.
+	Mod(a, m T) T
+	Gcd(a, b T) T
+	ExtGcd(a, b T) (d, m, n T)
+	Lcm(a, b T) T
+	IntModExp(x, e, m T) (T, error)
+	IntModRecip(x, m T) (T, error)
+	String(a T) string
+}
+
+// Int64Arith implements Arith[int64] with the package's int64 functions.
+type Int64Arith struct{}
+
+// BigArith implements Arith[*big.Int] with the package's big-integer functions.
+type BigArith struct{}
+
+var _ Arith[int64] = Int64Arith{}
+var _ Arith[*big.Int] = BigArith{}
+
+func (Int64Arith) FromInt64(n int64) int64 { return n }
+
+func (Int64Arith) Sign(a int64) int {
+	switch {
+	case a < 0:
+		return -1
+	case a > 0:
+		return 1
+	}
+	return 0
+}
+
+func (Int64Arith) Cmp(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (Int64Arith) Add(a, b int64) int64 { return a + b }
+func (Int64Arith) Sub(a, b int64) int64 { return a - b }
+func (Int64Arith) Mul(a, b int64) int64 { return a * b }
+func (Int64Arith) Quo(a, b int64) int64 { return a / b }
+
+func (Int64Arith) Mod(a, m int64) int64 {
+	if m < 0 {
+		m = -m
+	}
+	r := a % m
+	if r < 0 {
+		r += m
+	}
+	return r
+}
+
+func (Int64Arith) Gcd(a, b int64) int64                   { return Gcd(a, b) }
+func (Int64Arith) ExtGcd(a, b int64) (d, m, n int64)      { return ExtGcd(a, b) }
+func (Int64Arith) Lcm(a, b int64) int64                   { return Lcm(a, b) }
+func (Int64Arith) IntModExp(x, e, m int64) (int64, error) { return IntModExp(x, e, m) }
+func (Int64Arith) IntModRecip(x, m int64) (int64, error)  { return IntModRecip(x, m) }
+func (Int64Arith) String(a int64) string                  { return strconv.FormatInt(a, 10) }
+
+func (BigArith) FromInt64(n int64) *big.Int { return big.NewInt(n) }
+func (BigArith) Sign(a *big.Int) int        { return a.Sign() }
+func (BigArith) Cmp(a, b *big.Int) int      { return a.Cmp(b) }
+func (BigArith) Add(a, b *big.Int) *big.Int { return new(big.Int).Add(a, b) }
+func (BigArith) Sub(a, b *big.Int) *big.Int { return new(big.Int).Sub(a, b) }
+func (BigArith) Mul(a, b *big.Int) *big.Int { return new(big.Int).Mul(a, b) }
+func (BigArith) Quo(a, b *big.Int) *big.Int { return new(big.Int).Quo(a, b) }
+func (BigArith) Mod(a, m *big.Int) *big.Int { return new(big.Int).Mod(a, new(big.Int).Abs(m)) }
+func (BigArith) Gcd(a, b *big.Int) *big.Int { return BigGcd(a, b) }
+func (BigArith) Lcm(a, b *big.Int) *big.Int { return BigLcm(a, b) }
+func (BigArith) String(a *big.Int) string   { return a.String() }
+
+func (BigArith) ExtGcd(a, b *big.Int) (d, m, n *big.Int) { return BigExtGcd(a, b) }
+
+func (BigArith) IntModExp(x, e, m *big.Int) (*big.Int, error) { return BigIntModExp(x, e, m) }
+func (BigArith) IntModRecip(x, m *big.Int) (*big.Int, error)  { return BigIntModRecip(x, m) }
@@ -0,0 +1,88 @@
+package intarith
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Big-integer counterparts of the int64 functions, for moduli and group orders such as
+// 2^127-1 that do not fit in 64 bits. They follow the int64 versions' conventions,
+// including signs, and never modify their arguments.
+
+var bigOne = big.NewInt(1)
+
+// BigGcd returns the nonnegative gcd of a and b; BigGcd(0, 0) is 0.
+func BigGcd(a, b *big.Int) *big.Int {
+	rv := new(big.Int).Abs(a)
+	return rv.GCD(nil, nil, rv, new(big.Int).Abs(b))
+}
+
+// BigExtGcd returns (d, m, n) with d = a*m + b*n, by the same steps as ExtGcd, so the two
+// agree on values that fit in an int64.
+func BigExtGcd(a, b *big.Int) (d, m, n *big.Int) {
+	if b.Sign() == 0 {
+		return new(big.Int).Abs(a), big.NewInt(int64(a.Sign())), new(big.Int)
+	}
+	if a.Sign() == 0 {
+		return new(big.Int).Abs(b), new(big.Int), big.NewInt(int64(b.Sign()))
+	}
+	mprime, n := big.NewInt(1), big.NewInt(1)
+	m, nprime := new(big.Int), new(big.Int)
+	c, d := new(big.Int).Set(a), new(big.Int).Set(b)
+	q, r, t := new(big.Int), new(big.Int), new(big.Int)
+	for {
+		q.QuoRem(c, d, r)
+		if r.Sign() == 0 {
+			break
+		}
+		c, d, r = d, r, c
+		t.Mul(q, m)
+		mprime, m = m, mprime.Sub(mprime, t)
+		t.Mul(q, n)
+		nprime, n = n, nprime.Sub(nprime, t)
+	}
+	return d, m, n
+}
+
+// BigLcm returns the nonnegative least common multiple of a and b, or 0 if either is 0.
+func BigLcm(a, b *big.Int) *big.Int {
+	if a.Sign() == 0 || b.Sign() == 0 {
+		return new(big.Int)
+	}
+	rv := new(big.Int).Quo(a, BigGcd(a, b))
+	rv.Mul(rv, b)
+	return rv.Abs(rv)
+}
+
+// BigIntModExp returns x^e mod m in 0..|m|-1. Negative e uses the inverse of x.
+func BigIntModExp(x, e, m *big.Int) (*big.Int, error) {
+	if m.Sign() == 0 {
+		return nil, fmt.Errorf("intmodexp: zero modulus")
+	}
+	mabs := new(big.Int).Abs(m)
+	base := new(big.Int).Mod(x, mabs)
+	if e.Sign() < 0 {
+		recip, err := BigIntModRecip(base, mabs)
+		if err != nil {
+			return nil, err
+		}
+		return recip.Exp(recip, new(big.Int).Neg(e), mabs), nil
+	}
+	return base.Exp(base, e, mabs), nil
+}
+
+// BigIntModRecip returns the inverse of x mod m in 0..|m|-1.
+func BigIntModRecip(x, m *big.Int) (*big.Int, error) {
+	if m.Sign() == 0 {
+		return nil, fmt.Errorf("no modular inverse for %v mod 0", x)
+	}
+	mabs := new(big.Int).Abs(m)
+	if mabs.Cmp(bigOne) == 0 {
+		return new(big.Int), nil
+	}
+	rv := new(big.Int).Mod(x, mabs)
+	if rv.ModInverse(rv, mabs) == nil {
+		return nil, fmt.Errorf("no modular inverse for %v mod %v", x, m)
+	}
+	return rv, nil
+}
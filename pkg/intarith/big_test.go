@@ -0,0 +1,66 @@
+package intarith
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigMatchesInt64(t *testing.T) {
+	values := []int64{-60, -24, -7, -1, 0, 1, 2, 7, 11, 24, 60, 65}
+	for _, a := range values {
+		for _, b := range values {
+			ba, bb := big.NewInt(a), big.NewInt(b)
+			if got, want := BigGcd(ba, bb), Gcd(a, b); got.Int64() != abs(want) {
+				t.Errorf("BigGcd(%d,%d) = %v, want %d", a, b, got, abs(want))
+			}
+			d, m, n := BigExtGcd(ba, bb)
+			wd, wm, wn := ExtGcd(a, b)
+			if d.Int64() != wd || m.Int64() != wm || n.Int64() != wn {
+				t.Errorf("BigExtGcd(%d,%d) = (%v,%v,%v), want (%d,%d,%d)", a, b, d, m, n, wd, wm, wn)
+			}
+			if got, want := BigLcm(ba, bb), abs(Lcm(a, b)); got.Int64() != want {
+				t.Errorf("BigLcm(%d,%d) = %v, want %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+func abs(a int64) int64 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func TestBigIntModExp(t *testing.T) {
+	// 2^127-1 is prime, so 3^(m-1) = 1 and 3^-1 * 3 = 1 mod m.
+	m := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))
+	three := big.NewInt(3)
+	got, err := BigIntModExp(three, new(big.Int).Sub(m, big.NewInt(1)), m)
+	if err != nil || got.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("3^(m-1) mod m = %v, %v; want 1", got, err)
+	}
+	inv, err := BigIntModExp(three, big.NewInt(-1), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prod := new(big.Int).Mul(inv, three); prod.Mod(prod, m).Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("3^-1 mod m = %v is not an inverse", inv)
+	}
+	if _, err := BigIntModExp(three, big.NewInt(2), new(big.Int)); err == nil {
+		t.Error("BigIntModExp mod 0 should fail")
+	}
+}
+
+func TestBigIntModRecip(t *testing.T) {
+	got, err := BigIntModRecip(big.NewInt(2), big.NewInt(11))
+	if err != nil || got.Int64() != 6 {
+		t.Errorf("BigIntModRecip(2,11) = %v, %v; want 6, nil", got, err)
+	}
+	if _, err := BigIntModRecip(big.NewInt(2), big.NewInt(4)); err == nil {
+		t.Error("BigIntModRecip(2,4) should fail (no inverse)")
+	}
+	if _, err := BigIntModRecip(big.NewInt(2), new(big.Int)); err == nil {
+		t.Error("BigIntModRecip(2,0) should fail")
+	}
+}
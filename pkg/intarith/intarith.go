@@ -2,14 +2,15 @@ package intarith
 
 import (
 	"fmt"
-	"sync"
+	"math"
+	"math/bits"
+
+	"github.com/johnkerl/goffl/pkg/factorization"
+	"github.com/johnkerl/goffl/pkg/internal/trialdiv"
 )
 
 // Integer arithmetic: gcd, extended gcd, lcm, totient, modular exponentiation.
 
-var eulerPhiCache = make(map[int64]int64)
-var eulerPhiMu sync.Mutex
-
 func Gcd(a, b int64) int64 {
 	if a == 0 {
 		return b
@@ -68,7 +69,7 @@ func ExtGcd(a, b int64) (d, m, n int64) {
 }
 
 // Lcm returns the least common multiple of a and b. Lcm(a, 0) and Lcm(0, b) are 0.
-// May overflow for large |a|, |b|.
+// May overflow for large |a|, |b|; use LcmChecked when that can happen.
 func Lcm(a, b int64) int64 {
 	if a == 0 || b == 0 {
 		return 0
@@ -76,25 +77,72 @@ func Lcm(a, b int64) int64 {
 	return a * b / Gcd(a, b)
 }
 
-func EulerPhi(n int64) int64 {
-	eulerPhiMu.Lock()
-	defer eulerPhiMu.Unlock()
-	if v, ok := eulerPhiCache[n]; ok {
-		return v
+// LcmChecked is Lcm, returning an OverflowError if the result does not fit in an int64.
+func LcmChecked(a, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	rv, ok := mulChecked(a/Gcd(a, b), b)
+	if !ok {
+		return 0, &OverflowError{Op: "lcm"}
+	}
+	return rv, nil
+}
+
+// mulChecked returns a*b, or false if it overflows.
+func mulChecked(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
 	}
+	c := a * b
+	if c/b != a {
+		return 0, false
+	}
+	return c, true
+}
+
+// MulMod returns a*b mod |m| in 0..|m|-1. The product is formed in 128 bits, so it is
+// correct for every nonzero int64 modulus.
+func MulMod(a, b, m int64) int64 {
+	um := uint64(m)
+	if m < 0 {
+		um = -um
+	}
+	hi, lo := bits.Mul64(reduce(a, um), reduce(b, um))
+	return int64(bits.Rem64(hi, lo, um))
+}
+
+// reduce returns a mod m in 0..m-1 as a uint64, for m > 0.
+func reduce(a int64, m uint64) uint64 {
+	if a >= 0 {
+		return uint64(a) % m
+	}
+	r := (-uint64(a)) % m
+	if r == 0 {
+		return 0
+	}
+	return m - r
+}
+
+// EulerPhi returns the number of 1 <= a < n coprime to n, or 0 for n <= 1. It factors n
+// by trial division up to sqrt(n) and applies factorization's EulerPhi; callers with a
+// factorization in hand, or needing faster factoring, should use that directly.
+func EulerPhi(n int64) int64 {
 	if n <= 1 {
 		return 0
 	}
-	phi := int64(0)
-	for i := int64(1); i < n; i++ {
-		if Gcd(n, i) == 1 {
-			phi++
-		}
+	finfo := factorization.New()
+	if n = trialdiv.Divide(n, trialdiv.Candidates(), math.MaxInt64, finfo); n > 1 {
+		finfo.InsertFactor(n, 1)
 	}
-	eulerPhiCache[n] = phi
-	return phi
+	return finfo.EulerPhi()
 }
 
+// IntExp returns x^e, wrapping silently on overflow; use IntExpChecked when that can
+// happen.
 func IntExp(x, e int64) (int64, error) {
 	if e < 0 {
 		return 0, &NegativeExponentError{E: e}
@@ -111,7 +159,36 @@ func IntExp(x, e int64) (int64, error) {
 	return rv, nil
 }
 
+// IntExpChecked is IntExp, returning an OverflowError if x^e does not fit in an int64.
+func IntExpChecked(x, e int64) (int64, error) {
+	if e < 0 {
+		return 0, &NegativeExponentError{E: e}
+	}
+	xp := x
+	rv := int64(1)
+	var ok bool
+	for e != 0 {
+		if e&1 == 1 {
+			if rv, ok = mulChecked(rv, xp); !ok {
+				return 0, &OverflowError{Op: "intexp"}
+			}
+		}
+		e >>= 1
+		if e != 0 {
+			if xp, ok = mulChecked(xp, xp); !ok {
+				return 0, &OverflowError{Op: "intexp"}
+			}
+		}
+	}
+	return rv, nil
+}
+
+// IntModExp returns x^e mod |m| in 0..|m|-1. Negative e uses the inverse of x. Products
+// go through MulMod, so any nonzero int64 modulus works.
 func IntModExp(x, e, m int64) (int64, error) {
+	if m == 0 {
+		return 0, fmt.Errorf("intmodexp: zero modulus")
+	}
 	if e < 0 {
 		e = -e
 		recip, err := IntModRecip(x, m)
@@ -121,25 +198,31 @@ func IntModExp(x, e, m int64) (int64, error) {
 		x = recip
 	}
 	xp := x
-	rv := int64(1)
+	rv := MulMod(1, 1, m)
 	for e != 0 {
 		if e&1 == 1 {
-			rv = (rv * xp) % m
+			rv = MulMod(rv, xp, m)
 		}
 		e >>= 1
-		xp = (xp * xp) % m
+		xp = MulMod(xp, xp, m)
 	}
 	return rv, nil
 }
 
+// IntModRecip returns the inverse of x mod |m| in 0..|m|-1, from the extended gcd.
 func IntModRecip(x, m int64) (int64, error) {
-	if Gcd(x, m) != 1 {
+	if m == 0 {
+		return 0, fmt.Errorf("no modular inverse for %d mod 0", x)
+	}
+	d, s, _ := ExtGcd(x, m)
+	if d != 1 && d != -1 {
 		return 0, fmt.Errorf("no modular inverse for %d mod %d", x, m)
 	}
-	phi := EulerPhi(m)
-	return IntModExp(x, phi-1, m)
+	return MulMod(s, d, m), nil
 }
 
+// Factorial returns n!, wrapping silently past 20!; use FactorialChecked when that can
+// happen.
 func Factorial(n int64) (int64, error) {
 	if n < 0 {
 		return 0, &NegativeInputError{}
@@ -154,6 +237,22 @@ func Factorial(n int64) (int64, error) {
 	return rv, nil
 }
 
+// FactorialChecked is Factorial, returning an OverflowError if n! does not fit in an
+// int64.
+func FactorialChecked(n int64) (int64, error) {
+	if n < 0 {
+		return 0, &NegativeInputError{}
+	}
+	rv := int64(1)
+	var ok bool
+	for k := int64(2); k <= n; k++ {
+		if rv, ok = mulChecked(rv, k); !ok {
+			return 0, &OverflowError{Op: "factorial"}
+		}
+	}
+	return rv, nil
+}
+
 type NegativeExponentError struct{ E int64 }
 
 func (e *NegativeExponentError) Error() string { return "intexp: negative exponent disallowed" }
@@ -161,3 +260,8 @@ func (e *NegativeExponentError) Error() string { return "intexp: negative expone
 type NegativeInputError struct{}
 
 func (e *NegativeInputError) Error() string { return "factorial: negative input disallowed" }
+
+// OverflowError reports that the result of Op does not fit in an int64.
+type OverflowError struct{ Op string }
+
+func (e *OverflowError) Error() string { return e.Op + ": result overflows int64" }
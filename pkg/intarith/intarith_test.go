@@ -1,6 +1,10 @@
 package intarith
 
-import "testing"
+import (
+	"errors"
+	"math"
+	"testing"
+)
 
 func TestGcd(t *testing.T) {
 	if got := Gcd(0, 0); got != 0 {
@@ -92,3 +96,69 @@ func TestLcm(t *testing.T) {
 		t.Errorf("Lcm(4,0) = %d, want 0", got)
 	}
 }
+
+func TestMulMod(t *testing.T) {
+	const m = 1<<62 + 135 // well past where a*b fits in an int64
+	tests := []struct {
+		a, b, m, want int64
+	}{
+		{3, 4, 5, 2},
+		{-3, 4, 5, 3},
+		{3, 4, -5, 2},
+		{m - 1, m - 1, m, 1},
+		{m - 1, 2, m, m - 2},
+		{math.MaxInt64, math.MaxInt64, math.MaxInt64, 0},
+		{math.MinInt64, 1, 3, 1},
+	}
+	for _, tt := range tests {
+		if got := MulMod(tt.a, tt.b, tt.m); got != tt.want {
+			t.Errorf("MulMod(%d,%d,%d) = %d, want %d", tt.a, tt.b, tt.m, got, tt.want)
+		}
+	}
+	// 2^61-1 is prime, so Fermat gives 3^(p-1) = 1.
+	const p = 1<<61 - 1
+	if got, err := IntModExp(3, p-1, p); err != nil || got != 1 {
+		t.Errorf("IntModExp(3,p-1,p) = %d, %v; want 1, nil", got, err)
+	}
+}
+
+func TestChecked(t *testing.T) {
+	if got, err := LcmChecked(1<<40, 3<<40); err != nil || got != 3<<40 {
+		t.Errorf("LcmChecked(2^40, 3*2^40) = %d, %v", got, err)
+	}
+	if _, err := LcmChecked(1<<40+1, 1<<40-1); err == nil {
+		t.Error("LcmChecked(2^40+1, 2^40-1) should overflow")
+	}
+	if got, err := IntExpChecked(-2, 63); err != nil || got != math.MinInt64 {
+		t.Errorf("IntExpChecked(-2,63) = %d, %v", got, err)
+	}
+	if _, err := IntExpChecked(2, 63); err == nil {
+		t.Error("IntExpChecked(2,63) should overflow")
+	}
+	if got, err := IntExpChecked(3, 39); err != nil || got != 4052555153018976267 {
+		t.Errorf("IntExpChecked(3,39) = %d, %v", got, err)
+	}
+	if got, err := FactorialChecked(20); err != nil || got != 2432902008176640000 {
+		t.Errorf("FactorialChecked(20) = %d, %v", got, err)
+	}
+	var overflow *OverflowError
+	if _, err := FactorialChecked(21); !errors.As(err, &overflow) {
+		t.Errorf("FactorialChecked(21) error %v, want OverflowError", err)
+	}
+}
+
+func TestEulerPhiLarge(t *testing.T) {
+	// 2^31-1 is prime and 10^12 = 2^12 5^12.
+	if got := EulerPhi(1<<31 - 1); got != 1<<31-2 {
+		t.Errorf("EulerPhi(2^31-1) = %d", got)
+	}
+	if got := EulerPhi(1000000000000); got != 400000000000 {
+		t.Errorf("EulerPhi(10^12) = %d", got)
+	}
+	if got, err := IntModRecip(3, 1<<61-1); err != nil || MulMod(got, 3, 1<<61-1) != 1 {
+		t.Errorf("IntModRecip(3, 2^61-1) = %d, %v", got, err)
+	}
+	if got, err := IntModRecip(-2, 11); err != nil || got != 5 {
+		t.Errorf("IntModRecip(-2, 11) = %d, %v; want 5", got, err)
+	}
+}
@@ -0,0 +1,65 @@
+package intarith
+
+import "math/bits"
+
+// Legendre returns the Legendre symbol (a/p) for an odd prime p: 0 if p divides a, 1 if
+// a is a nonzero square mod p, -1 otherwise. It is Jacobi restricted to primes, which
+// are not checked.
+func Legendre(a, p int64) int { return Jacobi(a, p) }
+
+// Jacobi returns the Jacobi symbol (a/n) for odd n > 0, by quadratic reciprocity, without
+// factoring n. It panics if n is even or not positive.
+func Jacobi(a, n int64) int {
+	if n <= 0 || n%2 == 0 {
+		panic("jacobi: n must be odd and positive")
+	}
+	a = int64(reduce(a, uint64(n)))
+	t := 1
+	for a != 0 {
+		for a%2 == 0 {
+			a /= 2
+			if r := n % 8; r == 3 || r == 5 {
+				t = -t
+			}
+		}
+		a, n = n, a
+		if a%4 == 3 && n%4 == 3 {
+			t = -t
+		}
+		a %= n
+	}
+	if n == 1 {
+		return t
+	}
+	return 0
+}
+
+// Kronecker returns the Kronecker symbol (a/n), which extends Jacobi to every n: (a/-1)
+// is -1 for negative a and 1 otherwise; (a/2) is 0 for even a, 1 for a = +-1 mod 8 and
+// -1 for a = +-3 mod 8; and (a/0) is 1 for a = +-1 and 0 otherwise.
+func Kronecker(a, n int64) int {
+	if n == 0 {
+		if a == 1 || a == -1 {
+			return 1
+		}
+		return 0
+	}
+	t := 1
+	un := uint64(n)
+	if n < 0 {
+		un = -un
+		if a < 0 {
+			t = -t
+		}
+	}
+	v := bits.TrailingZeros64(un)
+	if v > 0 {
+		if a%2 == 0 {
+			return 0
+		}
+		if r := reduce(a, 8); v%2 == 1 && (r == 3 || r == 5) {
+			t = -t
+		}
+	}
+	return t * Jacobi(a, int64(un>>v))
+}
@@ -0,0 +1,61 @@
+package intarith
+
+import (
+	"math"
+	"testing"
+)
+
+func TestJacobi(t *testing.T) {
+	// Compare with Euler's criterion for primes and with the product over factors of n.
+	primes := []int64{3, 5, 7, 11, 13, 17, 19, 23}
+	for _, p := range primes {
+		for a := int64(-30); a <= 30; a++ {
+			want := 0
+			if a%p != 0 {
+				want = 1
+				if e, _ := IntModExp(a, (p-1)/2, p); e != 1 {
+					want = -1
+				}
+			}
+			if got := Legendre(a, p); got != want {
+				t.Errorf("Legendre(%d,%d) = %d, want %d", a, p, got, want)
+			}
+		}
+	}
+	for _, p := range primes {
+		for _, q := range primes {
+			for a := int64(-30); a <= 30; a++ {
+				if got, want := Jacobi(a, p*q), Legendre(a, p)*Legendre(a, q); got != want {
+					t.Errorf("Jacobi(%d,%d) = %d, want %d", a, p*q, got, want)
+				}
+			}
+		}
+	}
+	if got := Jacobi(1001, 9907); got != -1 {
+		t.Errorf("Jacobi(1001,9907) = %d, want -1", got)
+	}
+}
+
+func TestKronecker(t *testing.T) {
+	tests := []struct {
+		a, n int64
+		want int
+	}{
+		{1, 0, 1}, {-1, 0, 1}, {2, 0, 0},
+		{3, 2, -1}, {7, 2, 1}, {4, 2, 0}, {5, 4, 1}, {3, 8, -1},
+		{-3, -1, -1}, {3, -1, 1}, {-5, -7, -1}, {5, -7, -1},
+		{3, math.MinInt64, -1}, {2, 15, 1}, {-1, 12, -1}, {-1, 6, -1},
+	}
+	for _, tt := range tests {
+		if got := Kronecker(tt.a, tt.n); got != tt.want {
+			t.Errorf("Kronecker(%d,%d) = %d, want %d", tt.a, tt.n, got, tt.want)
+		}
+	}
+	for n := int64(1); n < 50; n += 2 {
+		for a := int64(-10); a <= 10; a++ {
+			if Kronecker(a, n) != Jacobi(a, n) {
+				t.Errorf("Kronecker(%d,%d) differs from Jacobi", a, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package intarith
+
+import (
+	"math"
+	"math/bits"
+)
+
+// cipollaMinTwoAdicity is where SqrtModPrime switches from Tonelli-Shanks to Cipolla.
+// Tonelli-Shanks takes O(s^2) multiplications when 2^s exactly divides p-1, Cipolla a
+// constant number per bit of p, so Cipolla wins once s is large.
+const cipollaMinTwoAdicity = 16
+
+// SqrtModPrime returns a square root of a mod the prime p, or false if a is not a square.
+// It uses Tonelli-Shanks, falling back to Cipolla when p-1 is divisible by a high power
+// of 2. The other root, if different, is p minus this one. p is not checked for
+// primality.
+func SqrtModPrime(a, p int64) (int64, bool) {
+	a = int64(reduce(a, uint64(p)))
+	if p == 2 || a == 0 {
+		return a, true
+	}
+	if Legendre(a, p) != 1 {
+		return 0, false
+	}
+	if bits.TrailingZeros64(uint64(p-1)) >= cipollaMinTwoAdicity {
+		return cipolla(a, p), true
+	}
+	return tonelliShanks(a, p), true
+}
+
+// addMod returns a+b mod m for a, b in 0..m-1; the sum is formed in a uint64.
+func addMod(a, b, m int64) int64 {
+	s := uint64(a) + uint64(b)
+	if s >= uint64(m) {
+		s -= uint64(m)
+	}
+	return int64(s)
+}
+
+// tonelliShanks returns a square root of the nonzero square a mod the odd prime p.
+func tonelliShanks(a, p int64) int64 {
+	if p%4 == 3 {
+		r, _ := IntModExp(a, (p+1)/4, p)
+		return r
+	}
+	s := bits.TrailingZeros64(uint64(p - 1))
+	q := (p - 1) >> s
+	z := int64(2)
+	for Legendre(z, p) != -1 {
+		z++
+	}
+	c, _ := IntModExp(z, q, p)
+	t, _ := IntModExp(a, q, p)
+	r, _ := IntModExp(a, (q+1)/2, p)
+	for m := s; t != 1; {
+		// Find the least i with t^(2^i) = 1; then i < m.
+		i, t2i := 0, t
+		for t2i != 1 {
+			t2i = MulMod(t2i, t2i, p)
+			i++
+		}
+		b := c
+		for j := 0; j < m-i-1; j++ {
+			b = MulMod(b, b, p)
+		}
+		m = i
+		c = MulMod(b, b, p)
+		t = MulMod(t, c, p)
+		r = MulMod(r, b, p)
+	}
+	return r
+}
+
+// cipolla returns a square root of the nonzero square a mod the odd prime p, as
+// (w + sqrt(w^2-a))^((p+1)/2) in F_p(sqrt(w^2-a)) for some w making w^2-a a non-square.
+func cipolla(a, p int64) int64 {
+	w, d := int64(0), int64(0)
+	for w = 1; ; w++ {
+		d = addMod(MulMod(w, w, p), p-a, p)
+		if Legendre(d, p) == -1 {
+			break
+		}
+	}
+	// Elements are x + y*sqrt(d).
+	mul := func(x1, y1, x2, y2 int64) (int64, int64) {
+		x := addMod(MulMod(x1, x2, p), MulMod(MulMod(y1, y2, p), d, p), p)
+		y := addMod(MulMod(x1, y2, p), MulMod(x2, y1, p), p)
+		return x, y
+	}
+	rx, ry := int64(1), int64(0)
+	bx, by := w, int64(1)
+	for e := (p + 1) / 2; e != 0; e >>= 1 {
+		if e&1 == 1 {
+			rx, ry = mul(rx, ry, bx, by)
+		}
+		bx, by = mul(bx, by, bx, by)
+	}
+	return rx
+}
+
+// Isqrt returns floor(sqrt(n)) for n >= 0. It panics for negative n.
+func Isqrt(n int64) int64 {
+	if n < 0 {
+		panic("isqrt: negative input disallowed")
+	}
+	r := int64(math.Sqrt(float64(n)))
+	// The float estimate can be off by one either way near 2^63.
+	for r > 0 && r > n/r {
+		r--
+	}
+	for r+1 <= n/(r+1) {
+		r++
+	}
+	return r
+}
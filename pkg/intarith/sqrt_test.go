@@ -0,0 +1,51 @@
+package intarith
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSqrtModPrime(t *testing.T) {
+	// 97 and 193 have p-1 divisible by 32 and 64, so both paths see several squarings.
+	for _, p := range []int64{2, 3, 5, 13, 17, 97, 193, 257} {
+		isSquare := make(map[int64]bool)
+		for x := int64(0); x < p; x++ {
+			isSquare[MulMod(x, x, p)] = true
+		}
+		for a := int64(0); a < p; a++ {
+			r, ok := SqrtModPrime(a, p)
+			if ok != isSquare[a] {
+				t.Errorf("SqrtModPrime(%d,%d) ok = %v", a, p, ok)
+			}
+			if ok && MulMod(r, r, p) != a {
+				t.Errorf("SqrtModPrime(%d,%d) = %d", a, p, r)
+			}
+			if ok && a != 0 && p > 2 {
+				for name, f := range map[string]func(a, p int64) int64{"tonelliShanks": tonelliShanks, "cipolla": cipolla} {
+					if r := f(a, p); MulMod(r, r, p) != a {
+						t.Errorf("%s(%d,%d) = %d", name, a, p, r)
+					}
+				}
+			}
+		}
+	}
+	// Large primes: 2^61-1 is 3 mod 4; 2^62-2^16+1 has 2^16 dividing p-1, taking Cipolla.
+	for _, p := range []int64{1<<61 - 1, 1<<62 - 1<<16 + 1} {
+		for _, x := range []int64{2, 12345, 1<<40 + 7, p - 3} {
+			a := MulMod(x, x, p)
+			r, ok := SqrtModPrime(a, p)
+			if !ok || MulMod(r, r, p) != a {
+				t.Errorf("SqrtModPrime(%d,%d) = %d, %v", a, p, r, ok)
+			}
+		}
+	}
+}
+
+func TestIsqrt(t *testing.T) {
+	for _, n := range []int64{0, 1, 2, 3, 4, 15, 16, 17, 1<<62 - 1, 1 << 62, math.MaxInt64, 3037000499 * 3037000499} {
+		r := Isqrt(n)
+		if r*r > n || (r+1) <= n/(r+1) {
+			t.Errorf("Isqrt(%d) = %d", n, r)
+		}
+	}
+}
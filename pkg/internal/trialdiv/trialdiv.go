@@ -0,0 +1,42 @@
+// Package trialdiv holds the trial division shared by intarith and intfactor.
+package trialdiv
+
+import (
+	"iter"
+
+	"github.com/johnkerl/goffl/pkg/factorization"
+)
+
+// Candidates yields 2 and then every odd number, in place of a table of primes. The
+// composites among them never divide, since their prime factors are gone by then.
+func Candidates() iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		if !yield(2) {
+			return
+		}
+		for p := int64(3); p > 0; p += 2 {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// Divide divides n >= 1 by each of the increasing candidates in turn, stopping at the
+// first that is at least limit or above the square root of what is left. It inserts the
+// primes it finds into finfo and returns the cofactor, which is 1, a prime, or a number
+// with no prime factor below limit.
+func Divide(n int64, candidates iter.Seq[int64], limit int64, finfo *factorization.Factorization) int64 {
+	for p := range candidates {
+		if p >= limit || p > n/p {
+			break
+		}
+		multiplicity := 0
+		for n%p == 0 {
+			multiplicity++
+			n /= p
+		}
+		finfo.InsertFactor(p, multiplicity)
+	}
+	return n
+}
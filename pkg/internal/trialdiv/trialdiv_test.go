@@ -0,0 +1,37 @@
+package trialdiv
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/factorization"
+)
+
+func TestDivide(t *testing.T) {
+	tests := []struct {
+		n, limit int64
+		want     string
+		cofactor int64
+	}{
+		{360, 100, "2^3 3^2", 5},
+		{97, 100, "", 97},
+		{1001 * 1009, 12, "7 11", 13 * 1009},
+		{1 << 40, 3, "2^40", 1},
+	}
+	for _, tt := range tests {
+		finfo := factorization.New()
+		if got := Divide(tt.n, Candidates(), tt.limit, finfo); got != tt.cofactor {
+			t.Errorf("Divide(%d, %d) = %d, want %d", tt.n, tt.limit, got, tt.cofactor)
+		}
+		if got := finfo.String(); got != tt.want {
+			t.Errorf("Divide(%d, %d) found %q, want %q", tt.n, tt.limit, got, tt.want)
+		}
+	}
+	// A table of primes gives the same result as the odd numbers.
+	a, b := factorization.New(), factorization.New()
+	n := int64(2 * 2 * 3 * 5 * 49 * 1000003)
+	if Divide(n, Candidates(), 1000, a) != Divide(n, slices.Values([]int64{2, 3, 5, 7, 11}), 1000, b) ||
+		a.String() != b.String() {
+		t.Errorf("Divide(%d): %v with Candidates, %v with primes", n, a, b)
+	}
+}
@@ -0,0 +1,149 @@
+package intfactor
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/johnkerl/goffl/pkg/factorization"
+	"github.com/johnkerl/goffl/pkg/primes"
+)
+
+// bigTrialLimit bounds the trial division BigFactor does before switching to Pollard rho.
+const bigTrialLimit = 1 << 12
+
+// BigFactor factors n by trial division of small primes, then by Pollard's rho method
+// with Brent's cycle detection for factors up to about 2^32, then by QuadraticSieve,
+// using primes.IsPrimeBig to stop. Cofactors that fit in an int64 go to Factor. The
+// sieve's time is set by the size of the composite it splits rather than of its factors:
+// well under a second at 40 digits, some tens of seconds at 60.
+func BigFactor(n *big.Int) *factorization.BigFactorization {
+	finfo := factorization.NewBig()
+	if n.CmpAbs(big.NewInt(1)) <= 0 {
+		finfo.InsertTrivialFactor(n)
+		return finfo
+	}
+	n = new(big.Int).Set(n)
+	if n.Sign() < 0 {
+		finfo.InsertTrivialFactor(big.NewInt(-1))
+		n.Neg(n)
+	}
+
+	p := new(big.Int)
+	q, r := new(big.Int), new(big.Int)
+	for d := int64(2); d < bigTrialLimit; d++ {
+		p.SetInt64(d)
+		if p.Mul(p, p).Cmp(n) > 0 {
+			break
+		}
+		p.SetInt64(d)
+		multiplicity := 0
+		for {
+			q.QuoRem(n, p, r)
+			if r.Sign() != 0 {
+				break
+			}
+			n.Set(q)
+			multiplicity++
+		}
+		if multiplicity > 0 {
+			finfo.InsertFactor(p, multiplicity)
+		}
+	}
+	if n.Cmp(big.NewInt(1)) != 0 {
+		bigFactorCofactor(n, finfo)
+	}
+	return finfo
+}
+
+// BigTotient returns Euler's phi of n >= 1, computed from BigFactor(n).
+func BigTotient(n *big.Int) *big.Int {
+	finfo := BigFactor(n)
+	rv := new(big.Int).Set(n)
+	pm1 := new(big.Int)
+	for i := 0; i < finfo.NumDistinctFactors(); i++ {
+		p, _ := finfo.Get(i)
+		rv.Quo(rv, p)
+		rv.Mul(rv, pm1.Sub(p, big.NewInt(1)))
+	}
+	return rv
+}
+
+// bigRhoRounds bounds the first Pollard rho pass of BigFactor on a composite, which
+// finds factors up to about 2^32 before the quadratic sieve takes over.
+const bigRhoRounds = 1 << 16
+
+// bigFactorCofactor inserts the prime factors of n > 1, which has no factors below the
+// trial division limit, into finfo. Parts that fit in an int64 go to Factor's engine;
+// larger composites get a short run of Pollard rho for small factors, then the quadratic
+// sieve, with unbounded rho as the fallback for the prime powers the sieve cannot split.
+func bigFactorCofactor(n *big.Int, finfo *factorization.BigFactorization) {
+	if n.IsInt64() {
+		sub := factorization.New()
+		factorCofactor(uint64(n.Int64()), sub)
+		finfo.Merge(sub.ToBig())
+		return
+	}
+	if primes.IsPrimeBig(n) {
+		finfo.InsertFactor(n, 1)
+		return
+	}
+	d := bigRhoBrent(n, big.NewInt(1), bigRhoRounds)
+	if d == nil {
+		d, _ = QuadraticSieve(n)
+	}
+	for c := int64(2); d == nil; c++ {
+		d = bigRhoBrent(n, big.NewInt(c), math.MaxInt)
+	}
+	bigFactorCofactor(d, finfo)
+	bigFactorCofactor(new(big.Int).Quo(n, d), finfo)
+}
+
+// bigRhoBrent returns a proper factor of composite n by iterating x -> x^2 + c, or nil if
+// this c fails or the cycle search passes maxRound. Differences are multiplied together
+// so that only one gcd is taken per batch.
+func bigRhoBrent(n, c *big.Int, maxRound int) *big.Int {
+	const batch = 128
+	one := big.NewInt(1)
+	y := big.NewInt(2)
+	x, ys := new(big.Int), new(big.Int)
+	q, g := big.NewInt(1), big.NewInt(1)
+	diff := new(big.Int)
+	step := func(v *big.Int) {
+		v.Mul(v, v)
+		v.Add(v, c)
+		v.Mod(v, n)
+	}
+
+	for r := 1; g.Cmp(one) == 0; r *= 2 {
+		if r > maxRound {
+			return nil
+		}
+		x.Set(y)
+		for i := 0; i < r; i++ {
+			step(y)
+		}
+		for k := 0; k < r && g.Cmp(one) == 0; k += batch {
+			ys.Set(y)
+			for i := 0; i < min(batch, r-k); i++ {
+				step(y)
+				q.Mul(q, diff.Sub(x, y).Abs(diff))
+				q.Mod(q, n)
+			}
+			g.GCD(nil, nil, q, n)
+		}
+	}
+	if g.Cmp(n) == 0 {
+		// The batch overshot; redo it one step at a time.
+		for {
+			step(ys)
+			g.GCD(nil, nil, diff.Sub(x, ys).Abs(diff), n)
+			if g.Cmp(one) != 0 {
+				break
+			}
+		}
+	}
+	if g.Cmp(n) == 0 {
+		return nil
+	}
+	return g
+}
@@ -2,10 +2,29 @@
 package intfactor
 
 import (
+	"fmt"
+	"slices"
+	"sync"
+
 	"github.com/johnkerl/goffl/pkg/factorization"
 	"github.com/johnkerl/goffl/pkg/intarith"
+	"github.com/johnkerl/goffl/pkg/internal/trialdiv"
+	"github.com/johnkerl/goffl/pkg/primes"
 )
 
+// smallPrimeLimit bounds the table of primes that FactorRange sieves with.
+const smallPrimeLimit = 1 << 16
+
+// trialLimit bounds the primes Factor divides out before handing the cofactor to the
+// engine in split.go.
+const trialLimit = 1 << 12
+
+var smallPrimes = sync.OnceValue(func() []int64 { return primes.Sieve(smallPrimeLimit) })
+
+// Factor factors n by trial division over the primes below 2^12, then splits what is
+// left with Pollard's rho method (Brent's variant), falling back to SQUFOF and then to
+// Lenstra's elliptic-curve method, and using primes.IsPrime to stop. Any int64 factors
+// in well under a second.
 func Factor(n int64) *factorization.Factorization {
 	finfo := factorization.New()
 	if n >= -1 && n <= 1 {
@@ -17,23 +36,57 @@ func Factor(n int64) *factorization.Factorization {
 		finfo.InsertTrivialFactor(&minusOne)
 		n = -n
 	}
-	p := int64(2)
-	for n > 1 {
-		multiplicity := 0
-		for n%p == 0 {
-			multiplicity++
-			n /= p
+	n = trialdiv.Divide(n, slices.Values(smallPrimes()), trialLimit, finfo)
+	if n >= trialLimit*trialLimit {
+		factorCofactor(uint64(n), finfo)
+	} else if n > 1 {
+		finfo.InsertFactor(n, 1)
+	}
+	return finfo
+}
+
+// FactorRange returns the factorizations of lo, lo+1, ..., hi for 1 <= lo <= hi. Like a
+// segmented sieve, it visits only the multiples of each prime up to sqrt(hi), so the
+// whole range costs about as much as sieving it.
+func FactorRange(lo, hi int64) ([]*factorization.Factorization, error) {
+	if lo < 1 || hi < lo {
+		return nil, fmt.Errorf("factor_range: need 1 <= lo <= hi; got %d, %d", lo, hi)
+	}
+	out := make([]*factorization.Factorization, hi-lo+1)
+	rem := make([]int64, len(out))
+	for i := range out {
+		out[i] = factorization.New()
+		rem[i] = lo + int64(i)
+	}
+	if lo == 1 {
+		one := int64(1)
+		out[0].InsertTrivialFactor(&one)
+	}
+	ps := smallPrimes()
+	if r := intarith.Isqrt(hi); r > smallPrimeLimit {
+		ps = primes.Sieve(r)
+	}
+	for _, p := range ps {
+		if p > hi/p {
+			break
 		}
-		if multiplicity > 0 {
-			finfo.InsertFactor(p, multiplicity)
+		for m := (lo + p - 1) / p * p; m <= hi; m += p {
+			i := m - lo
+			multiplicity := 0
+			for rem[i]%p == 0 {
+				multiplicity++
+				rem[i] /= p
+			}
+			out[i].InsertFactor(p, multiplicity)
 		}
-		if p > 2 {
-			p += 2
-		} else {
-			p += 1
+	}
+	// What is left has no factor up to sqrt(hi), so it is 1 or prime.
+	for i, r := range rem {
+		if r > 1 {
+			out[i].InsertFactor(r, 1)
 		}
 	}
-	return finfo
+	return out, nil
 }
 
 func SlowTotient(n int64) int64 {
@@ -46,14 +99,8 @@ func SlowTotient(n int64) int64 {
 	return count
 }
 
+// Totient returns Euler's phi of n from its factorization; see
+// factorization.Factorization.EulerPhi.
 func Totient(n int64) int64 {
-	finfo := Factor(n)
-	rv := n
-	for i := 0; i < finfo.NumDistinctFactors(); i++ {
-		p, e := finfo.Get(i)
-		for j := 0; j < e; j++ {
-			rv = rv * (p - 1) / p
-		}
-	}
-	return rv
+	return Factor(n).EulerPhi()
 }
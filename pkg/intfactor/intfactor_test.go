@@ -1,6 +1,7 @@
 package intfactor
 
 import (
+	"math/big"
 	"reflect"
 	"testing"
 )
@@ -17,6 +18,41 @@ func TestFactor(t *testing.T) {
 	}
 }
 
+func TestFactorLarge(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{1<<61 - 1, "2305843009213693951"},
+		{2 * (1<<61 - 1), "2 2305843009213693951"},
+		{65537 * 65539 * 65543, "65537 65539 65543"},
+		{4294967291 * 1000003, "1000003 4294967291"},
+	}
+	for _, tt := range tests {
+		if got := Factor(tt.n).String(); got != tt.want {
+			t.Errorf("Factor(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFactorRange(t *testing.T) {
+	for _, r := range [][2]int64{{1, 2000}, {1<<40 - 500, 1<<40 + 500}} {
+		got, err := FactorRange(r[0], r[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, finfo := range got {
+			n := r[0] + int64(i)
+			if want := Factor(n); finfo.String() != want.String() {
+				t.Errorf("FactorRange: %d = %s, want %s", n, finfo, want)
+			}
+		}
+	}
+	if _, err := FactorRange(0, 10); err == nil {
+		t.Error("FactorRange(0, 10) should fail")
+	}
+}
+
 func TestTotient(t *testing.T) {
 	if got := Totient(1); got != 1 {
 		t.Errorf("Totient(1) = %d, want 1", got)
@@ -27,4 +63,44 @@ func TestTotient(t *testing.T) {
 	if got := Totient(10); got != 4 {
 		t.Errorf("Totient(10) = %d, want 4", got)
 	}
+	for n := int64(1); n < 200; n++ {
+		if got, want := Totient(n), SlowTotient(n); n > 1 && got != want {
+			t.Errorf("Totient(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestBigFactor(t *testing.T) {
+	one := big.NewInt(1)
+	m31 := big.NewInt(1<<31 - 1)
+	m127 := new(big.Int).Sub(new(big.Int).Lsh(one, 127), one)
+	tests := []struct {
+		n    *big.Int
+		want string
+	}{
+		{big.NewInt(0), "0"},
+		{big.NewInt(1), "1"},
+		{big.NewInt(-360), "-1 2^3 3^2 5"},
+		{new(big.Int).Mul(m31, m31), "2147483647^2"},
+		{m127, m127.String()},
+		{big.NewInt(1000003 * 1000033), "1000003 1000033"},
+		{new(big.Int).Mul(m127, big.NewInt(4093*4099)), "4093 4099 " + m127.String()},
+	}
+	for _, tt := range tests {
+		finfo := BigFactor(tt.n)
+		if got := finfo.String(); got != tt.want {
+			t.Errorf("BigFactor(%v) = %s, want %s", tt.n, got, tt.want)
+		}
+		if got := finfo.Unfactor(); got.Cmp(tt.n) != 0 {
+			t.Errorf("BigFactor(%v).Unfactor() = %v", tt.n, got)
+		}
+	}
+}
+
+func TestBigTotient(t *testing.T) {
+	for _, n := range []int64{1, 7, 10, 72, 1000} {
+		if got, want := BigTotient(big.NewInt(n)).Int64(), Totient(n); got != want {
+			t.Errorf("BigTotient(%d) = %d, want %d", n, got, want)
+		}
+	}
 }
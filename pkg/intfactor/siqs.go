@@ -0,0 +1,449 @@
+package intfactor
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"math/rand"
+	"sort"
+
+	"github.com/johnkerl/goffl/pkg/bitmatrix"
+	"github.com/johnkerl/goffl/pkg/intarith"
+	"github.com/johnkerl/goffl/pkg/primes"
+)
+
+// qsParams gives the factor-base size and the sieve half-width M, by the bit length of n.
+// Larger n needs more primes to find smooth values at all, and a wider interval to
+// amortize each polynomial's setup.
+var qsParams = []struct{ maxBits, fbSize, halfWidth int }{
+	{50, 40, 1 << 11},
+	{70, 60, 1 << 12},
+	{85, 80, 1 << 13},
+	{100, 120, 1 << 14},
+	{120, 250, 1 << 15},
+	{140, 1000, 1 << 15},
+	{160, 1400, 1 << 16},
+	{180, 2200, 1 << 17},
+	{200, 4500, 1 << 17},
+	{220, 6000, 1 << 17},
+	{math.MaxInt, 8000, 1 << 17},
+}
+
+// qsExtraRelations is how many relations past the factor-base size the sieve collects;
+// each one adds a dependency, and each dependency splits n with probability at least 1/2.
+const qsExtraRelations = 32
+
+// qsSieveMin is the smallest prime the sieve adds in. Smaller ones cost the most passes
+// for the fewest bits, so they are left to trial division, and qsSlack makes room for
+// them along with 2 and prime powers.
+const qsSieveMin = 32
+
+// qsLargePrimeMult bounds the one prime above the factor base that a partial relation
+// may have, as a multiple of the largest base prime.
+const qsLargePrimeMult = 64
+
+// chooseA widens its search for an unused a every qsWidenEvery draws, and gives up after
+// qsMaxATries.
+const (
+	qsWidenEvery = 20
+	qsMaxATries  = 2000
+)
+
+// qsSlack lowers the sieve threshold, in bits, below the size of the values being
+// sieved less one large prime.
+const qsSlack = 5
+
+// QuadraticSieve returns a proper factor of the composite n > 1 by the self-initializing
+// quadratic sieve. It looks for values of (a*x+b)^2 - n that factor over a base of small
+// primes, for many polynomials a*x+b cheaply derived from each other, and then combines
+// these relations into x^2 = y^2 mod n using the GF(2) kernel of their exponent vectors
+// (bitmatrix.KernelBasis). Its time grows as exp(sqrt(ln n ln ln n)), so it overtakes
+// Pollard rho once n has no factor much below its square root; n should not be a prime
+// power other than a square.
+func QuadraticSieve(n *big.Int) (*big.Int, error) {
+	if n.Cmp(big.NewInt(4)) < 0 {
+		return nil, fmt.Errorf("quadratic_sieve: need composite n > 1; got %v", n)
+	}
+	if n.Bit(0) == 0 {
+		return big.NewInt(2), nil
+	}
+	if primes.IsPrimeBig(n) {
+		return nil, fmt.Errorf("quadratic_sieve: %v is prime", n)
+	}
+	if r := new(big.Int).Sqrt(n); new(big.Int).Mul(r, r).Cmp(n) == 0 {
+		return r, nil
+	}
+	qs, d := newQSieve(n)
+	if d != nil {
+		return d, nil
+	}
+	rels, err := qs.collect()
+	if err != nil {
+		return nil, err
+	}
+	return qs.combine(rels)
+}
+
+// qsieve holds the factor base for n: fb[0] is 2, and the odd primes p in fb have n a
+// nonzero square mod p, with sqrtN[i] one of its square roots mod fb[i].
+type qsieve struct {
+	n         *big.Int
+	fb        []int64
+	fbBig     []*big.Int
+	sqrtN     []int64
+	logp      []uint8
+	halfWidth int
+	threshold uint8
+
+	largePrimeBound int64
+}
+
+// qsRelation records y^2 = q mod n with q smooth, or with q one large prime times a smooth
+// number for a partial relation. Each smooth prime factor appears in columns once per
+// power: column 0 for the sign and column i+1 for fb[i]. A full relation made from two
+// partials has q divisible by large^2, and large otherwise 1.
+type qsRelation struct {
+	y       *big.Int
+	columns []int
+	large   int64
+}
+
+// newQSieve builds the factor base for n, or returns a factor-base prime that divides n.
+func newQSieve(n *big.Int) (*qsieve, *big.Int) {
+	params := qsParams[0]
+	for _, params = range qsParams {
+		if n.BitLen() <= params.maxBits {
+			break
+		}
+	}
+	qs := &qsieve{n: n, halfWidth: params.halfWidth}
+	r := new(big.Int)
+	// About half the primes qualify, so sieve a little past the fbSize*2-th prime.
+	for limit := int64(4 * params.fbSize * bits.Len(uint(params.fbSize))); ; limit *= 2 {
+		qs.fb = qs.fb[:0]
+		qs.sqrtN = qs.sqrtN[:0]
+		for _, p := range primes.Sieve(limit) {
+			if len(qs.fb) == params.fbSize {
+				break
+			}
+			nmodp := r.Mod(n, big.NewInt(p)).Int64()
+			if p == 2 {
+				qs.fb, qs.sqrtN = append(qs.fb, 2), append(qs.sqrtN, nmodp)
+				continue
+			}
+			if nmodp == 0 {
+				return nil, big.NewInt(p)
+			}
+			if t, ok := intarith.SqrtModPrime(nmodp, p); ok {
+				qs.fb, qs.sqrtN = append(qs.fb, p), append(qs.sqrtN, t)
+			}
+		}
+		if len(qs.fb) == params.fbSize {
+			break
+		}
+	}
+	qs.fbBig = make([]*big.Int, len(qs.fb))
+	qs.logp = make([]uint8, len(qs.fb))
+	for i, p := range qs.fb {
+		qs.fbBig[i] = big.NewInt(p)
+		qs.logp[i] = uint8(math.Round(math.Log2(float64(p))))
+	}
+	// Values (a*x+b)^2 - n over a*x+b, for |x| <= M, are at most about M*sqrt(n/2).
+	pmax := qs.fb[len(qs.fb)-1]
+	qs.largePrimeBound = min(pmax*qsLargePrimeMult, pmax*pmax)
+	target := bits.Len(uint(qs.halfWidth)) + (n.BitLen()-1)/2 - bits.Len64(uint64(qs.largePrimeBound)) - qsSlack
+	qs.threshold = uint8(max(target, 1))
+	return qs, nil
+}
+
+// chooseA returns a product a of s factor-base primes near sqrt(2n)/M, so that the
+// values on the sieve interval are as small as they can be, and the indices of its
+// primes. It draws s-1 primes at random from those near the s-th root of the target and
+// picks the last to bring the product closest, skipping the products in used. When those
+// run out it widens the window and lets the last prime stray from the closest, and it
+// returns nil once qsMaxATries draws in a row have all been used.
+func (qs *qsieve) chooseA(used map[string]bool) (*big.Int, []int) {
+	target := new(big.Int).Lsh(qs.n, 1)
+	target.Sqrt(target)
+	target.Quo(target, big.NewInt(int64(qs.halfWidth)))
+	nfb := len(qs.fb)
+	logMid := math.Log2(float64(qs.fb[nfb/2]))
+	s := max(1, int(math.Round(float64(target.BitLen())/logMid)))
+	nearest := func(v float64) int {
+		i := sort.Search(nfb, func(k int) bool { return float64(qs.fb[k]) >= v })
+		return min(max(i, 1), nfb-1)
+	}
+	center := nearest(math.Exp2(float64(target.BitLen()) / float64(s)))
+	span := max(nfb/10, 2*s)
+
+	for attempt := 0; attempt < qsMaxATries; attempt++ {
+		widen := attempt / qsWidenEvery
+		w := span << min(widen, 16)
+		lo, hi := max(1, center-w), min(nfb, center+w)
+		a, idx := big.NewInt(1), make([]int, 0, s)
+		chosen := map[int]bool{}
+		for len(idx) < s-1 && len(chosen) < hi-lo {
+			i := lo + rand.Intn(hi-lo)
+			if !chosen[i] {
+				chosen[i] = true
+				idx = append(idx, i)
+				a.Mul(a, qs.fbBig[i])
+			}
+		}
+		rest, _ := new(big.Int).Quo(target, a).Float64()
+		i := nearest(rest)
+		if widen > 0 {
+			i = min(max(i+rand.Intn(2*widen+1)-widen, 1), nfb-1)
+		}
+		for chosen[i] {
+			i = 1 + i%(nfb-1)
+		}
+		idx = append(idx, i)
+		a.Mul(a, qs.fbBig[i])
+		if key := a.String(); !used[key] {
+			used[key] = true
+			return a, idx
+		}
+	}
+	return nil, nil
+}
+
+// collect sieves polynomials until it has enough relations for a dependency. For each a,
+// the b with b^2 = n mod a are the sums of +-B_l, one B_l per prime of a; stepping
+// through them in Gray-code order changes one sign at a time, so the roots of the next
+// polynomial mod each base prime need only integer updates. A value y met again, from
+// another polynomial or as -y, is skipped: its relation would only pair with the first
+// into the trivial x = y.
+func (qs *qsieve) collect() ([]qsRelation, error) {
+	want := len(qs.fb) + 1 + qsExtraRelations
+	sieve := make([]uint8, 2*qs.halfWidth)
+	nfb := len(qs.fb)
+	ainv, bmod := make([]int64, nfb), make([]int64, nfb)
+	root1, root2 := make([]int, nfb), make([]int, nfb)
+	isA := make([]bool, nfb)
+	used := map[string]bool{}
+	seen := map[string]bool{}
+	partials := map[int64]qsRelation{}
+	var rels []qsRelation
+
+	for len(rels) < want {
+		a, aIdx := qs.chooseA(used)
+		if a == nil {
+			return nil, fmt.Errorf("quadratic_sieve: ran out of polynomials with %d of %d relations",
+				len(rels), want)
+		}
+		for _, i := range aIdx {
+			isA[i] = true
+		}
+		s := len(aIdx)
+		bl := make([]*big.Int, s)
+		blmod := make([][]int64, s)
+		b := new(big.Int)
+		for l, qi := range aIdx {
+			q := qs.fb[qi]
+			aOverQ := new(big.Int).Quo(a, qs.fbBig[qi])
+			inv, _ := intarith.IntModRecip(new(big.Int).Mod(aOverQ, qs.fbBig[qi]).Int64(), q)
+			gamma := intarith.MulMod(qs.sqrtN[qi], inv, q)
+			bl[l] = aOverQ.Mul(aOverQ, big.NewInt(gamma))
+			b.Add(b, bl[l])
+			blmod[l] = make([]int64, nfb)
+		}
+		r := new(big.Int)
+		for i := 1; i < nfb; i++ {
+			if isA[i] {
+				continue
+			}
+			p := qs.fbBig[i]
+			ainv[i], _ = intarith.IntModRecip(r.Mod(a, p).Int64(), qs.fb[i])
+			bmod[i] = r.Mod(b, p).Int64()
+			for l := range bl {
+				blmod[l][i] = r.Mod(bl[l], p).Int64()
+			}
+		}
+
+		negative := make([]bool, s)
+		for poly := 0; poly < 1<<(s-1) && len(rels) < want; poly++ {
+			if poly > 0 {
+				v := bits.TrailingZeros(uint(poly)) + 1
+				if negative[v] {
+					b.Add(b, bl[v])
+					b.Add(b, bl[v])
+				} else {
+					b.Sub(b, bl[v])
+					b.Sub(b, bl[v])
+				}
+				for i := 1; i < nfb; i++ {
+					if isA[i] {
+						continue
+					}
+					p := qs.fb[i]
+					delta := 2 * blmod[v][i] % p
+					if negative[v] {
+						bmod[i] = (bmod[i] + delta) % p
+					} else {
+						bmod[i] = (bmod[i] + p - delta) % p
+					}
+				}
+				negative[v] = !negative[v]
+			}
+
+			qs.fillSieve(sieve, isA, ainv, bmod, root1, root2)
+
+			for j, v := range sieve {
+				if v < qs.threshold {
+					continue
+				}
+				rel, ok := qs.trialDivide(a, b, j, isA, root1, root2)
+				if !ok {
+					continue
+				}
+				key := new(big.Int).Abs(rel.y).String()
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				if rel.large == 1 {
+					rels = append(rels, rel)
+				} else if other, ok := partials[rel.large]; !ok {
+					partials[rel.large] = rel
+				} else {
+					// Two values sharing one large prime multiply to a full relation
+					// with that prime squared.
+					rels = append(rels, qsRelation{
+						y:       new(big.Int).Mul(rel.y, other.y),
+						columns: append(rel.columns, other.columns...),
+						large:   rel.large,
+					})
+				}
+			}
+		}
+		for _, i := range aIdx {
+			isA[i] = false
+		}
+	}
+	return rels, nil
+}
+
+// fillSieve adds log p into sieve at each index j = x + M where p divides (a*x+b)^2 - n,
+// for the base primes p not dividing a, and stores the two roots mod p in root1 and
+// root2 for trial division. a and b enter through a^-1 and b mod p.
+func (qs *qsieve) fillSieve(sieve []uint8, isA []bool, ainv, bmod []int64, root1, root2 []int) {
+	clear(sieve)
+	for i := 1; i < len(qs.fb); i++ {
+		if isA[i] {
+			continue
+		}
+		// The roots are x = (+-t - b)/a mod p.
+		p := qs.fb[i]
+		t := qs.sqrtN[i]
+		shift := int64(qs.halfWidth) % p
+		r1 := (intarith.MulMod(ainv[i], t+p-bmod[i], p) + shift) % p
+		r2 := (intarith.MulMod(ainv[i], 2*p-t-bmod[i], p) + shift) % p
+		root1[i], root2[i] = int(r1), int(r2)
+		if p < qsSieveMin {
+			continue
+		}
+		lp := qs.logp[i]
+		for j := root1[i]; j < len(sieve); j += int(p) {
+			sieve[j] += lp
+		}
+		if r2 != r1 {
+			for j := root2[i]; j < len(sieve); j += int(p) {
+				sieve[j] += lp
+			}
+		}
+	}
+}
+
+// trialDivide factors (a*x+b)^2 - n over the factor base for x = j - M, trying only the
+// primes whose roots say they divide it. It reports false if a cofactor remains.
+func (qs *qsieve) trialDivide(a, b *big.Int, j int, isA []bool, root1, root2 []int) (qsRelation, bool) {
+	y := big.NewInt(int64(j - qs.halfWidth))
+	y.Mul(y, a).Add(y, b)
+	q := new(big.Int).Mul(y, y)
+	q.Sub(q, qs.n)
+	var columns []int
+	if q.Sign() < 0 {
+		columns = append(columns, 0)
+		q.Neg(q)
+	}
+	if q.Sign() == 0 {
+		return qsRelation{}, false
+	}
+	tz := q.TrailingZeroBits()
+	q.Rsh(q, tz)
+	for range tz {
+		columns = append(columns, 1)
+	}
+	quo, rem := new(big.Int), new(big.Int)
+	for i := 1; i < len(qs.fb); i++ {
+		if !isA[i] {
+			jm := j % int(qs.fb[i])
+			if jm != root1[i] && jm != root2[i] {
+				continue
+			}
+		}
+		for {
+			quo.QuoRem(q, qs.fbBig[i], rem)
+			if rem.Sign() != 0 {
+				break
+			}
+			q, quo = quo, q
+			columns = append(columns, i+1)
+		}
+	}
+	// A cofactor below pmax^2 is prime, since primes below pmax that are not in the
+	// base cannot divide y^2 - n.
+	if !q.IsInt64() || q.Int64() > qs.largePrimeBound {
+		return qsRelation{}, false
+	}
+	return qsRelation{y: y, columns: columns, large: q.Int64()}, true
+}
+
+// combine finds subsets of relations whose product q is a square y^2, so that the product
+// x of their ys has x^2 = y^2 mod n, and returns the first gcd(x-y, n) that splits n.
+func (qs *qsieve) combine(rels []qsRelation) (*big.Int, error) {
+	m, err := bitmatrix.New(len(qs.fb)+1, len(rels))
+	if err != nil {
+		return nil, err
+	}
+	for j, rel := range rels {
+		for _, c := range rel.columns {
+			m.Rows[c].ToggleElement(j)
+		}
+	}
+	basis, err := m.KernelBasis()
+	if err != nil {
+		return nil, err
+	}
+	if basis == nil {
+		return nil, fmt.Errorf("quadratic_sieve: no dependencies among %d relations", len(rels))
+	}
+	one := big.NewInt(1)
+	exps := make([]int, len(qs.fb)+1)
+	for k := 0; k < basis.NumRows(); k++ {
+		clear(exps)
+		x, y := big.NewInt(1), big.NewInt(1)
+		for j := range basis.Row(k).SetPositions() {
+			x.Mul(x, rels[j].y).Mod(x, qs.n)
+			y.Mul(y, big.NewInt(rels[j].large)).Mod(y, qs.n)
+			for _, c := range rels[j].columns {
+				exps[c]++
+			}
+		}
+		pe := new(big.Int)
+		for c := 1; c < len(exps); c++ {
+			if exps[c] > 0 {
+				pe.Exp(qs.fbBig[c-1], big.NewInt(int64(exps[c]/2)), qs.n)
+				y.Mul(y, pe).Mod(y, qs.n)
+			}
+		}
+		d := new(big.Int).Sub(x, y)
+		d.GCD(nil, nil, d.Abs(d), qs.n)
+		if d.Cmp(one) != 0 && d.Cmp(qs.n) != 0 {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("quadratic_sieve: no dependency split %v", qs.n)
+}
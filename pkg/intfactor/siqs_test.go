@@ -0,0 +1,99 @@
+package intfactor
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/primes"
+)
+
+// nextPrime returns the least prime >= the decimal s.
+func nextPrime(s string) *big.Int {
+	p, _ := new(big.Int).SetString(s, 10)
+	for !primes.IsPrimeBig(p) {
+		p.Add(p, big.NewInt(1))
+	}
+	return p
+}
+
+func TestQuadraticSieve(t *testing.T) {
+	// Balanced semiprimes of 20, 30 and 40 digits, out of Pollard rho's easy reach.
+	for _, pq := range [][2]string{
+		{"3111111111", "7333333333"},
+		{"311111111111111", "733333333333333"},
+		{"31111111111111111111", "73333333333333333333"},
+	} {
+		p, q := nextPrime(pq[0]), nextPrime(pq[1])
+		n := new(big.Int).Mul(p, q)
+		d, err := QuadraticSieve(n)
+		if err != nil {
+			t.Errorf("QuadraticSieve(%v): %v", n, err)
+			continue
+		}
+		if d.Cmp(p) != 0 && d.Cmp(q) != 0 {
+			t.Errorf("QuadraticSieve(%v) = %v, want %v or %v", n, d, p, q)
+		}
+	}
+}
+
+// randomPrime returns a random prime of exactly the given bit length.
+func randomPrime(r *rand.Rand, nbits int) *big.Int {
+	p := new(big.Int).Rand(r, new(big.Int).Lsh(big.NewInt(1), uint(nbits-1)))
+	p.SetBit(p, nbits-1, 1)
+	for !primes.IsPrimeBig(p) {
+		p.Add(p, big.NewInt(1))
+	}
+	return p
+}
+
+func TestQuadraticSieveRandom(t *testing.T) {
+	// Small n leave few choices of the polynomial coefficient a and give the most
+	// repeated values, so they are covered as densely as the larger sizes.
+	r := rand.New(rand.NewSource(1))
+	for _, nbits := range []int{24, 40, 48, 56, 64, 80, 100} {
+		for range 20 {
+			p, q := randomPrime(r, nbits/2), randomPrime(r, nbits-nbits/2)
+			n := new(big.Int).Mul(p, q)
+			d, err := QuadraticSieve(n)
+			if err != nil {
+				t.Errorf("QuadraticSieve(%v): %v", n, err)
+				continue
+			}
+			if d.Cmp(p) != 0 && d.Cmp(q) != 0 {
+				t.Errorf("QuadraticSieve(%v) = %v, want %v or %v", n, d, p, q)
+			}
+		}
+	}
+}
+
+func TestQuadraticSieveEdges(t *testing.T) {
+	m127 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))
+	for _, n := range []*big.Int{big.NewInt(1), big.NewInt(3), m127} {
+		if d, err := QuadraticSieve(n); err == nil {
+			t.Errorf("QuadraticSieve(%v) = %v, want an error", n, d)
+		}
+	}
+	p := nextPrime("1000000000000000000000")
+	tests := []struct{ n, want *big.Int }{
+		{new(big.Int).Lsh(p, 1), big.NewInt(2)},
+		{new(big.Int).Mul(p, p), p},
+		{new(big.Int).Mul(p, big.NewInt(7)), big.NewInt(7)},
+	}
+	for _, tt := range tests {
+		if d, err := QuadraticSieve(tt.n); err != nil || d.Cmp(tt.want) != 0 {
+			t.Errorf("QuadraticSieve(%v) = %v, %v; want %v", tt.n, d, err, tt.want)
+		}
+	}
+}
+
+func TestBigFactorSieve(t *testing.T) {
+	// Two 18-digit primes times small ones, which trial division strips first.
+	p, q := nextPrime("311111111111111111"), nextPrime("733333333333333333")
+	n := new(big.Int).Mul(p, q)
+	n.Mul(n, big.NewInt(12))
+	want := "2^2 3 " + p.String() + " " + q.String()
+	if got := BigFactor(n).String(); got != want {
+		t.Errorf("BigFactor(%v) = %s, want %s", n, got, want)
+	}
+}
@@ -0,0 +1,299 @@
+package intfactor
+
+import (
+	"math"
+	"math/bits"
+	"math/rand"
+
+	"github.com/johnkerl/goffl/pkg/factorization"
+	"github.com/johnkerl/goffl/pkg/intarith"
+	"github.com/johnkerl/goffl/pkg/primes"
+)
+
+// Factor's engine past trial division. Each method returns a proper factor of a composite
+// n < 2^63 that has no prime factor below the trial-division limit, or 0 on failure; they
+// are tried from cheapest to most robust.
+
+// rhoMaxRound bounds Brent's rho; the factors it misses within it go to SQUFOF and ECM.
+const rhoMaxRound = 1 << 22
+
+// factorCofactor inserts the prime factors of n > 1 into finfo, splitting composites
+// until every part is prime.
+func factorCofactor(n uint64, finfo *factorization.Factorization) {
+	if primes.IsPrimeUint64(n) {
+		finfo.InsertFactor(int64(n), 1)
+		return
+	}
+	d := split(n)
+	// Divide out all of d's power at once so that, say, p^5 takes one split.
+	m, e := n/d, 1
+	for m%d == 0 {
+		m /= d
+		e++
+	}
+	sub := factorization.New()
+	factorCofactor(d, sub)
+	sub.ExpAll(e)
+	finfo.Merge(sub)
+	if m > 1 {
+		factorCofactor(m, finfo)
+	}
+}
+
+// split returns a proper factor of the composite n.
+func split(n uint64) uint64 {
+	if r := isqrt64(n); r*r == n {
+		return r
+	}
+	for c := uint64(1); c <= 3; c++ {
+		if d := rhoBrent(n, c); d != 0 {
+			return d
+		}
+	}
+	if d := squfof(n); d != 0 {
+		return d
+	}
+	for b1 := uint64(2000); ; b1 *= 2 {
+		for curve := 0; curve < 20; curve++ {
+			if d := ecm(n, b1); d != 0 {
+				return d
+			}
+		}
+	}
+}
+
+func mulMod(a, b, n uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, n)
+}
+
+// addMod returns a+b mod n for a, b in 0..n-1, without overflow for any n.
+func addMod(a, b, n uint64) uint64 {
+	if a >= n-b {
+		return a - (n - b)
+	}
+	return a + b
+}
+
+// subMod returns a-b mod n for a, b in 0..n-1.
+func subMod(a, b, n uint64) uint64 {
+	if a >= b {
+		return a - b
+	}
+	return a + (n - b)
+}
+
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func gcd64(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func isqrt64(n uint64) uint64 {
+	r := uint64(math.Sqrt(float64(n)))
+	for r > 0 && r > n/r {
+		r--
+	}
+	for r+1 <= n/(r+1) {
+		r++
+	}
+	return r
+}
+
+// rhoBrent runs Pollard's rho on x -> x^2 + c with Brent's cycle detection, multiplying
+// a batch of differences together before each gcd.
+func rhoBrent(n, c uint64) uint64 {
+	const batch = 128
+	f := func(v uint64) uint64 { return (mulMod(v, v, n) + c) % n }
+	y, x, ys := uint64(2), uint64(0), uint64(0)
+	q, g := uint64(1), uint64(1)
+	for r := 1; g == 1; r *= 2 {
+		if r > rhoMaxRound {
+			return 0
+		}
+		x = y
+		for i := 0; i < r; i++ {
+			y = f(y)
+		}
+		for k := 0; k < r && g == 1; k += batch {
+			ys = y
+			for i := 0; i < min(batch, r-k); i++ {
+				y = f(y)
+				q = mulMod(q, absDiff(x, y), n)
+			}
+			g = gcd64(q, n)
+		}
+	}
+	if g == n {
+		// The batch overshot; redo it one step at a time.
+		for g = 1; g == 1; {
+			ys = f(ys)
+			g = gcd64(absDiff(x, ys), n)
+		}
+	}
+	if g == n {
+		return 0
+	}
+	return g
+}
+
+// squfofMultipliers are the multipliers k tried by SQUFOF, which works on the square
+// forms of discriminant k*n.
+var squfofMultipliers = []uint64{
+	1, 3, 5, 7, 11, 3 * 5, 3 * 7, 3 * 11, 5 * 7, 5 * 11, 7 * 11,
+	3 * 5 * 7, 3 * 5 * 11, 3 * 7 * 11, 5 * 7 * 11, 3 * 5 * 7 * 11,
+}
+
+// squfof is Shanks' square forms factorization: it walks the continued fraction of
+// sqrt(k*n) to a square form, then walks back from its square root to an ambiguous form,
+// which reveals a factor. Its time grows as n^(1/4). Near 2^63 few multipliers keep k*n
+// in range and it fails more often, which ECM then covers. The uint64 arithmetic wraps in
+// intermediate steps but every true value is nonnegative and below 2^64.
+func squfof(n uint64) uint64 {
+	s := isqrt64(n)
+	if s*s == n {
+		return s
+	}
+	bound := 3 * 2 * isqrt64(2*s)
+	for _, k := range squfofMultipliers {
+		if n > math.MaxUint64/k {
+			continue
+		}
+		d := k * n
+		p0 := isqrt64(d)
+		pPrev, p := p0, p0
+		qPrev, q := uint64(1), d-p0*p0
+		if q == 0 {
+			if g := gcd64(n, p0); g != 1 && g != n {
+				return g
+			}
+			continue
+		}
+		var r uint64
+		i := uint64(2)
+		for ; i < bound; i++ {
+			b := (p0 + p) / q
+			p = b*q - p
+			qOld := q
+			q = qPrev + b*(pPrev-p)
+			r = isqrt64(q)
+			if i%2 == 0 && r*r == q {
+				break
+			}
+			qPrev, pPrev = qOld, p
+		}
+		if i >= bound {
+			continue
+		}
+		b := (p0 - p) / r
+		p = b*r + p
+		pPrev = p
+		qPrev = r
+		q = (d - pPrev*pPrev) / qPrev
+		if q == 0 {
+			continue
+		}
+		for j := uint64(0); j < bound; j++ {
+			b = (p0 + p) / q
+			pPrev = p
+			p = b*q - p
+			qOld := q
+			q = qPrev + b*(pPrev-p)
+			qPrev = qOld
+			if p == pPrev {
+				break
+			}
+		}
+		if g := gcd64(n, qPrev); g != 1 && g != n {
+			return g
+		}
+	}
+	return 0
+}
+
+// ecmPoint is an affine point on y^2 = x^3 + a*x + b mod n; inf marks the identity.
+type ecmPoint struct {
+	x, y uint64
+	inf  bool
+}
+
+// ecmCurve does point arithmetic mod n. A failed inversion stores the gcd it hit in
+// factor and makes every later operation a no-op.
+type ecmCurve struct {
+	n, a   uint64
+	factor uint64
+}
+
+func (c *ecmCurve) inverse(v uint64) uint64 {
+	d, s, _ := intarith.ExtGcd(int64(v), int64(c.n))
+	if d != 1 {
+		c.factor = uint64(d)
+		return 0
+	}
+	return uint64(intarith.MulMod(s, 1, int64(c.n)))
+}
+
+func (c *ecmCurve) add(p, q ecmPoint) ecmPoint {
+	if c.factor != 0 || p.inf {
+		return q
+	}
+	if q.inf {
+		return p
+	}
+	n := c.n
+	var lambda uint64
+	if p.x == q.x {
+		if addMod(p.y, q.y, n) == 0 {
+			return ecmPoint{inf: true}
+		}
+		// (3x^2 + a) / 2y
+		num := addMod(mulMod(3, mulMod(p.x, p.x, n), n), c.a, n)
+		lambda = mulMod(num, c.inverse(addMod(p.y, p.y, n)), n)
+	} else {
+		lambda = mulMod(subMod(q.y, p.y, n), c.inverse(subMod(q.x, p.x, n)), n)
+	}
+	x := subMod(subMod(mulMod(lambda, lambda, n), p.x, n), q.x, n)
+	y := subMod(mulMod(lambda, subMod(p.x, x, n), n), p.y, n)
+	return ecmPoint{x: x, y: y}
+}
+
+func (c *ecmCurve) mul(k uint64, p ecmPoint) ecmPoint {
+	r := ecmPoint{inf: true}
+	for ; k != 0 && c.factor == 0; k >>= 1 {
+		if k&1 == 1 {
+			r = c.add(r, p)
+		}
+		p = c.add(p, p)
+	}
+	return r
+}
+
+// ecm runs stage one of Lenstra's elliptic-curve method on one random curve through a
+// random point: it multiplies the point by every prime power up to b1, and a factor
+// turns up when the curve's group order mod some p | n is b1-smooth, as a failed inversion.
+func ecm(n, b1 uint64) uint64 {
+	c := &ecmCurve{n: n, a: rand.Uint64() % n}
+	p := ecmPoint{x: rand.Uint64() % n, y: rand.Uint64() % n}
+	for q := range primes.Range(2, int64(b1)) {
+		pe := uint64(q)
+		for pe <= b1/uint64(q) {
+			pe *= uint64(q)
+		}
+		p = c.mul(pe, p)
+		if c.factor != 0 {
+			break
+		}
+	}
+	if c.factor != 0 && c.factor != n {
+		return c.factor
+	}
+	return 0
+}
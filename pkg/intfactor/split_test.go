@@ -0,0 +1,94 @@
+package intfactor
+
+import (
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/primes"
+)
+
+// Semiprimes with no factor below trialLimit, from balanced to lopsided.
+var hardSemiprimes = []uint64{
+	2147483647 * 4294967291, // 2^31-1 times 2^32-5
+	1000000007 * 998244353,
+	4294967291 * 65537,
+	1000003 * 1000033,
+}
+
+func checkSplit(t *testing.T, name string, n, d uint64) {
+	t.Helper()
+	if d <= 1 || d >= n || n%d != 0 {
+		t.Errorf("%s(%d) = %d, not a proper factor", name, n, d)
+	}
+}
+
+func TestRhoBrent(t *testing.T) {
+	for _, n := range hardSemiprimes {
+		checkSplit(t, "rhoBrent", n, rhoBrent(n, 1))
+	}
+}
+
+func TestSqufof(t *testing.T) {
+	// Near 2^63 only the multiplier 1 fits and SQUFOF may fail, so skip the first.
+	for _, n := range hardSemiprimes[1:] {
+		checkSplit(t, "squfof", n, squfof(n))
+	}
+	if got := squfof(1000003 * 1000003); got != 1000003 {
+		t.Errorf("squfof(1000003^2) = %d, want 1000003", got)
+	}
+}
+
+func TestEcm(t *testing.T) {
+	// A factor near 2^20 needs only a few curves at b1 = 2000.
+	n := uint64(1000003 * 4294967291)
+	for curve := 0; curve < 200; curve++ {
+		if d := ecm(n, 2000); d != 0 {
+			checkSplit(t, "ecm", n, d)
+			return
+		}
+	}
+	t.Errorf("ecm(%d) found no factor in 200 curves", n)
+}
+
+func TestEcmNear63Bits(t *testing.T) {
+	// Above 2^64/3 the curve arithmetic must not overflow uint64; about one curve in six
+	// splits this one at b1 = 2000.
+	n := uint64(3037000453 * 3037000493)
+	for curve := 0; curve < 200; curve++ {
+		if d := ecm(n, 2000); d != 0 {
+			checkSplit(t, "ecm", n, d)
+			return
+		}
+	}
+	t.Errorf("ecm(%d) found no factor in 200 curves", n)
+}
+
+func TestFactorSplit(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{2147483647 * 4294967291, "2147483647 4294967291"},
+		{1000003 * 1000003 * 1000003, "1000003^3"},
+		{12 * 1000003 * 1000003 * 65537, "2^2 3 65537 1000003^2"},
+		{1<<59 - 1, "179951 3203431780337"},
+		{1<<62 - 1, "3 715827883 2147483647"},
+		{3037000453 * 3037000493, "3037000453 3037000493"},
+	}
+	for _, tt := range tests {
+		if got := Factor(tt.n).String(); got != tt.want {
+			t.Errorf("Factor(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+	// Every part must be prime and multiply back, across a spread of 62-bit inputs.
+	for n := int64(1<<62 + 1); n < 1<<62+200; n += 2 {
+		finfo := Factor(n)
+		if got := finfo.Unfactor(); got != n {
+			t.Errorf("Factor(%d).Unfactor() = %d", n, got)
+		}
+		for i := 0; i < finfo.NumDistinctFactors(); i++ {
+			if p, _ := finfo.Get(i); !primes.IsPrime(p) {
+				t.Errorf("Factor(%d) has composite part %d", n, p)
+			}
+		}
+	}
+}
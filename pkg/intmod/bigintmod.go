@@ -0,0 +1,139 @@
+package intmod
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/rand"
+
+	"github.com/johnkerl/goffl/pkg/intarith"
+)
+
+// BigIntMod is a residue class integer mod m for moduli that do not fit in an int64. It
+// mirrors IntMod; operations return new values and never modify their operands.
+type BigIntMod struct {
+	Residue *big.Int
+	modulus *big.Int
+}
+
+// NewBig returns residue mod |modulus|, reduced into 0..|modulus|-1. The modulus must be
+// nonzero.
+func NewBig(residue, modulus *big.Int) *BigIntMod {
+	m := new(big.Int).Abs(modulus)
+	return &BigIntMod{Residue: new(big.Int).Mod(residue, m), modulus: m}
+}
+
+// FromIntMod returns a as a BigIntMod.
+func FromIntMod(a *IntMod) *BigIntMod {
+	return &BigIntMod{Residue: big.NewInt(a.Residue), modulus: big.NewInt(a.modulus)}
+}
+
+// IntMod returns a as an IntMod, or false if the modulus does not fit in an int64.
+func (a *BigIntMod) IntMod() (*IntMod, bool) {
+	if !a.modulus.IsInt64() {
+		return nil, false
+	}
+	return &IntMod{Residue: a.Residue.Int64(), modulus: a.modulus.Int64()}, true
+}
+
+func (a *BigIntMod) Modulus() *big.Int { return new(big.Int).Set(a.modulus) }
+
+func (a *BigIntMod) String() string { return a.Residue.String() }
+
+func (a *BigIntMod) checkModulus(other *BigIntMod) {
+	if a.modulus.Cmp(other.modulus) != 0 {
+		panic("modulus mismatch")
+	}
+}
+
+func (a *BigIntMod) Recip() (*BigIntMod, error) {
+	r, err := intarith.BigIntModRecip(a.Residue, a.modulus)
+	if err != nil {
+		return nil, fmt.Errorf("intmod recip: %w", err)
+	}
+	return &BigIntMod{Residue: r, modulus: a.modulus}, nil
+}
+
+func (a *BigIntMod) Add(other *BigIntMod) *BigIntMod {
+	a.checkModulus(other)
+	r := new(big.Int).Add(a.Residue, other.Residue)
+	if r.Cmp(a.modulus) >= 0 {
+		r.Sub(r, a.modulus)
+	}
+	return &BigIntMod{Residue: r, modulus: a.modulus}
+}
+
+func (a *BigIntMod) Sub(other *BigIntMod) *BigIntMod {
+	a.checkModulus(other)
+	r := new(big.Int).Sub(a.Residue, other.Residue)
+	if r.Sign() < 0 {
+		r.Add(r, a.modulus)
+	}
+	return &BigIntMod{Residue: r, modulus: a.modulus}
+}
+
+func (a *BigIntMod) Neg() *BigIntMod {
+	r := new(big.Int)
+	if a.Residue.Sign() != 0 {
+		r.Sub(a.modulus, a.Residue)
+	}
+	return &BigIntMod{Residue: r, modulus: a.modulus}
+}
+
+func (a *BigIntMod) Mul(other *BigIntMod) *BigIntMod {
+	a.checkModulus(other)
+	r := new(big.Int).Mul(a.Residue, other.Residue)
+	return &BigIntMod{Residue: r.Mod(r, a.modulus), modulus: a.modulus}
+}
+
+func (a *BigIntMod) Div(other *BigIntMod) (*BigIntMod, error) {
+	rec, err := other.Recip()
+	if err != nil {
+		return nil, err
+	}
+	return a.Mul(rec), nil
+}
+
+func (a *BigIntMod) Pow(e *big.Int) (*BigIntMod, error) {
+	if a.Residue.Sign() == 0 {
+		if e.Sign() == 0 {
+			return nil, fmt.Errorf("0**0 undefined")
+		}
+		if e.Sign() < 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return &BigIntMod{Residue: new(big.Int), modulus: a.modulus}, nil
+	}
+	r, err := intarith.BigIntModExp(a.Residue, e, a.modulus)
+	if err != nil {
+		return nil, fmt.Errorf("intmod pow: %w", err)
+	}
+	return &BigIntMod{Residue: r, modulus: a.modulus}, nil
+}
+
+func (a *BigIntMod) Equal(other *BigIntMod) bool {
+	return a.Residue.Cmp(other.Residue) == 0 && a.modulus.Cmp(other.modulus) == 0
+}
+
+// RandomBig returns a uniformly random residue mod m, drawing candidates of m's bit
+// length from the package-level source until one is below m.
+func RandomBig(m *big.Int) *BigIntMod {
+	if m.Sign() == 0 {
+		panic("intmod: zero modulus")
+	}
+	mabs := new(big.Int).Abs(m)
+	nbits := mabs.BitLen()
+	nbytes := (nbits + 7) / 8
+	buf := make([]byte, (nbytes+7)/8*8)
+	r := new(big.Int)
+	for {
+		for k := 0; k < len(buf); k += 8 {
+			binary.BigEndian.PutUint64(buf[k:], rand.Uint64())
+		}
+		r.SetBytes(buf[:nbytes])
+		r.Rsh(r, uint(nbytes*8-nbits))
+		if r.Cmp(mabs) < 0 {
+			return &BigIntMod{Residue: r, modulus: mabs}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package intmod
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigBasic(t *testing.T) {
+	a := NewBig(big.NewInt(2), big.NewInt(11))
+	b := NewBig(big.NewInt(-8), big.NewInt(11))
+	if got := a.Add(b).Residue.Int64(); got != 5 {
+		t.Errorf("(a+b).Residue = %d, want 5", got)
+	}
+	if got := a.Sub(b).Residue.Int64(); got != 10 {
+		t.Errorf("(a-b).Residue = %d, want 10", got)
+	}
+	if got := a.Mul(b).Residue.Int64(); got != 6 {
+		t.Errorf("(a*b).Residue = %d, want 6", got)
+	}
+	pow, err := a.Pow(big.NewInt(10))
+	if err != nil || pow.Residue.Int64() != 1 {
+		t.Errorf("a^10 = %v, %v; want 1", pow, err)
+	}
+	small, ok := a.Mul(b).IntMod()
+	if !ok || !small.Equal(New(2, 11).Mul(New(3, 11))) {
+		t.Errorf("IntMod() = %v, %v", small, ok)
+	}
+}
+
+func TestBigRecip(t *testing.T) {
+	m := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))
+	for i := 0; i < 20; i++ {
+		a := RandomBig(m)
+		if a.Residue.Sign() == 0 {
+			continue
+		}
+		r, err := a.Recip()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := a.Mul(r).Residue; got.Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("(a*recip).Residue = %v, want 1", got)
+		}
+	}
+	if _, err := NewBig(big.NewInt(6), big.NewInt(9)).Recip(); err == nil {
+		t.Error("6 mod 9 should have no inverse")
+	}
+}
+
+func TestRandomBig(t *testing.T) {
+	m := big.NewInt(5)
+	seen := make(map[int64]bool)
+	for i := 0; i < 200; i++ {
+		r := RandomBig(m).Residue
+		if r.Sign() < 0 || r.Cmp(m) >= 0 {
+			t.Fatalf("RandomBig(5) = %v out of range", r)
+		}
+		seen[r.Int64()] = true
+	}
+	if len(seen) != 5 {
+		t.Errorf("RandomBig(5) hit %d of 5 residues", len(seen))
+	}
+}
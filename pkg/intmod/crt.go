@@ -0,0 +1,137 @@
+package intmod
+
+import (
+	"fmt"
+
+	"github.com/johnkerl/goffl/pkg/intarith"
+)
+
+// CRT returns the residue x mod lcm of the moduli with x congruent to every one of
+// residues. The moduli need not be coprime; if two residues disagree modulo the gcd of
+// their moduli, there is no such x and CRT returns an error. CRT of no residues is 0 mod 1.
+func CRT(residues []*IntMod) (*IntMod, error) {
+	x, m := int64(0), int64(1)
+	for _, a := range residues {
+		var err error
+		x, m, err = crtPair(x, m, a.Residue, a.modulus)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &IntMod{Residue: x, modulus: m}, nil
+}
+
+// crtPair combines x = a1 mod m1 and x = a2 mod m2, with residues reduced, into x mod
+// lcm(m1, m2). From m1*p + m2*q = g, x = a1 + m1*t where t = (a2-a1)/g * p mod m2/g; then
+// m1*t <= lcm - m1, so nothing overflows once the lcm fits.
+func crtPair(a1, m1, a2, m2 int64) (x, m int64, err error) {
+	g, p, _ := intarith.ExtGcd(m1, m2)
+	if (a2-a1)%g != 0 {
+		return 0, 0, fmt.Errorf("crt: %d mod %d and %d mod %d are inconsistent", a1, m1, a2, m2)
+	}
+	m, err = intarith.LcmChecked(m1, m2)
+	if err != nil {
+		return 0, 0, fmt.Errorf("crt: %w", err)
+	}
+	t := intarith.MulMod((a2-a1)/g, p, m2/g)
+	return a1 + m1*t, m, nil
+}
+
+// CRTSolver solves CRT systems over a fixed list of pairwise coprime moduli, using
+// Garner's algorithm with the inverses it needs computed once.
+type CRTSolver struct {
+	moduli []int64
+	// inverses[i] is the inverse of m_0 * ... * m_{i-1} mod m_i.
+	inverses []int64
+	product  int64
+}
+
+// NewCRTSolver returns a solver for the given moduli, which must be positive, pairwise
+// coprime, and have a product that fits in an int64.
+func NewCRTSolver(moduli []int64) (*CRTSolver, error) {
+	s := &CRTSolver{
+		moduli:   append([]int64(nil), moduli...),
+		inverses: make([]int64, len(moduli)),
+		product:  1,
+	}
+	for i, mi := range moduli {
+		if mi < 1 {
+			return nil, fmt.Errorf("crt: modulus %d is not positive", mi)
+		}
+		inv, err := intarith.IntModRecip(s.product, mi)
+		if err != nil {
+			return nil, fmt.Errorf("crt: modulus %d is not coprime to the ones before it", mi)
+		}
+		s.inverses[i] = inv
+		// Coprime, so the lcm is the product.
+		product, err := intarith.LcmChecked(s.product, mi)
+		if err != nil {
+			return nil, fmt.Errorf("crt: %w", err)
+		}
+		s.product = product
+	}
+	return s, nil
+}
+
+// Modulus returns the product of the solver's moduli.
+func (s *CRTSolver) Modulus() int64 { return s.product }
+
+// MixedRadix returns Garner's digits v for the residues: 0 <= v_i < m_i and
+// x = v_0 + v_1*m_0 + v_2*m_0*m_1 + ... is the solution. Residues are reduced first.
+func (s *CRTSolver) MixedRadix(residues []int64) ([]int64, error) {
+	if len(residues) != len(s.moduli) {
+		return nil, fmt.Errorf("crt: %d residues for %d moduli", len(residues), len(s.moduli))
+	}
+	v := make([]int64, len(residues))
+	for i, mi := range s.moduli {
+		// Evaluate v_0 + v_1*m_0 + ... + v_{i-1}*m_0*...*m_{i-2} mod m_i by Horner's rule.
+		acc := int64(0)
+		for j := i - 1; j >= 0; j-- {
+			acc = intarith.MulMod(acc, s.moduli[j], mi)
+			acc = (acc + v[j]%mi) % mi
+		}
+		diff := intarith.MulMod(residues[i], 1, mi) - acc
+		if diff < 0 {
+			diff += mi
+		}
+		v[i] = intarith.MulMod(diff, s.inverses[i], mi)
+	}
+	return v, nil
+}
+
+// Solve returns x mod the product of the moduli with x = residues[i] mod m_i for all i.
+func (s *CRTSolver) Solve(residues []int64) (*IntMod, error) {
+	v, err := s.MixedRadix(residues)
+	if err != nil {
+		return nil, err
+	}
+	return s.fromMixedRadix(v), nil
+}
+
+func (s *CRTSolver) fromMixedRadix(v []int64) *IntMod {
+	// Horner again; each partial value is below the product of the moduli used so far.
+	x := int64(0)
+	for i := len(v) - 1; i >= 0; i-- {
+		x = x*s.moduli[i] + v[i]
+	}
+	return &IntMod{Residue: x, modulus: s.product}
+}
+
+// Garner is CRT for pairwise coprime moduli by way of their mixed-radix digits. It
+// returns the combined residue and the digits; see CRTSolver.MixedRadix.
+func Garner(residues []*IntMod) (*IntMod, []int64, error) {
+	moduli := make([]int64, len(residues))
+	values := make([]int64, len(residues))
+	for i, a := range residues {
+		moduli[i], values[i] = a.modulus, a.Residue
+	}
+	s, err := NewCRTSolver(moduli)
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err := s.MixedRadix(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.fromMixedRadix(v), v, nil
+}
@@ -0,0 +1,95 @@
+package intmod
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCRT(t *testing.T) {
+	tests := []struct {
+		residues []*IntMod
+		want     *IntMod
+	}{
+		{nil, New(0, 1)},
+		{[]*IntMod{New(2, 3), New(3, 5), New(2, 7)}, New(23, 105)},
+		{[]*IntMod{New(3, 4), New(5, 6)}, New(11, 12)},
+		{[]*IntMod{New(1, 6), New(3, 10), New(13, 15)}, New(13, 30)},
+		{[]*IntMod{New(5, 1<<61), New(2, 3)}, New(5, 3<<61)},
+	}
+	for _, tt := range tests {
+		got, err := CRT(tt.residues)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("CRT(%v) = %v, %v; want %v", tt.residues, got, err, tt.want)
+		}
+	}
+	if _, err := CRT([]*IntMod{New(1, 4), New(2, 6)}); err == nil {
+		t.Error("1 mod 4 and 2 mod 6 should be inconsistent")
+	}
+	if _, err := CRT([]*IntMod{New(1, 1<<40+1), New(1, 1<<40-1)}); err == nil {
+		t.Error("lcm past int64 should fail")
+	}
+}
+
+func TestCRTBrute(t *testing.T) {
+	for m1 := int64(1); m1 <= 12; m1++ {
+		for m2 := int64(1); m2 <= 12; m2++ {
+			for a1 := int64(0); a1 < m1; a1++ {
+				for a2 := int64(0); a2 < m2; a2++ {
+					got, err := CRT([]*IntMod{New(a1, m1), New(a2, m2)})
+					want := int64(-1)
+					for x := int64(0); x < m1*m2; x++ {
+						if x%m1 == a1 && x%m2 == a2 {
+							want = x
+							break
+						}
+					}
+					if want < 0 {
+						if err == nil {
+							t.Errorf("%d mod %d, %d mod %d: got %v, want error", a1, m1, a2, m2, got)
+						}
+					} else if err != nil || got.Residue != want {
+						t.Errorf("%d mod %d, %d mod %d: got %v, %v; want %d", a1, m1, a2, m2, got, err, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGarner(t *testing.T) {
+	x, v, err := Garner([]*IntMod{New(2, 3), New(3, 5), New(2, 7)})
+	if err != nil || !x.Equal(New(23, 105)) {
+		t.Fatalf("Garner = %v, %v", x, err)
+	}
+	// 23 = 2 + 2*3 + 1*15
+	if !slices.Equal(v, []int64{2, 2, 1}) {
+		t.Errorf("mixed radix %v, want [2 2 1]", v)
+	}
+	if _, _, err := Garner([]*IntMod{New(1, 4), New(1, 6)}); err == nil {
+		t.Error("Garner should reject non-coprime moduli")
+	}
+}
+
+func TestCRTSolver(t *testing.T) {
+	moduli := []int64{1<<31 - 1, 1000003, 97}
+	s, err := NewCRTSolver(moduli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []int64{0, 1, 12345678901234, s.Modulus() - 1} {
+		residues := make([]int64, len(moduli))
+		for i, m := range moduli {
+			residues[i] = x % m
+		}
+		got, err := s.Solve(residues)
+		if err != nil || got.Residue != x || got.Modulus() != s.Modulus() {
+			t.Errorf("Solve(%v) = %v, %v; want %d", residues, got, err, x)
+		}
+	}
+	if _, err := s.Solve([]int64{1}); err == nil {
+		t.Error("Solve with too few residues should fail")
+	}
+	if _, err := NewCRTSolver([]int64{1 << 32, 1<<32 - 1}); err == nil {
+		t.Error("product past int64 should fail")
+	}
+}
@@ -39,11 +39,13 @@ func (a *IntMod) Add(other *IntMod) *IntMod {
 	if a.modulus != other.modulus {
 		panic("modulus mismatch")
 	}
-	r := (a.Residue + other.Residue) % a.modulus
-	if r < 0 {
-		r += a.modulus
+	// Residues are below the modulus, so the sum fits in a uint64 even when it does not
+	// fit in an int64.
+	r := uint64(a.Residue) + uint64(other.Residue)
+	if r >= uint64(a.modulus) {
+		r -= uint64(a.modulus)
 	}
-	return &IntMod{Residue: r, modulus: a.modulus}
+	return &IntMod{Residue: int64(r), modulus: a.modulus}
 }
 
 func (a *IntMod) Sub(other *IntMod) *IntMod {
@@ -69,10 +71,7 @@ func (a *IntMod) Mul(other *IntMod) *IntMod {
 	if a.modulus != other.modulus {
 		panic("modulus mismatch")
 	}
-	r := (a.Residue * other.Residue) % a.modulus
-	if r < 0 {
-		r += a.modulus
-	}
+	r := intarith.MulMod(a.Residue, other.Residue, a.modulus)
 	return &IntMod{Residue: r, modulus: a.modulus}
 }
 
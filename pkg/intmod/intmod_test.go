@@ -41,3 +41,19 @@ func TestUnits(t *testing.T) {
 		t.Errorf("len(UnitsForModulus(10)) = %d, want %d", len(units), want)
 	}
 }
+
+func TestLargeModulus(t *testing.T) {
+	const m = 1<<63 - 25 // prime
+	a := New(m-1, m)
+	if got := a.Add(a).Residue; got != m-2 {
+		t.Errorf("(-1)+(-1) = %d, want %d", got, m-2)
+	}
+	if got := a.Mul(a).Residue; got != 1 {
+		t.Errorf("(-1)*(-1) = %d, want 1", got)
+	}
+	b := New(1<<62+12345, m)
+	pow, err := b.Pow(m - 1)
+	if err != nil || pow.Residue != 1 {
+		t.Errorf("b^(m-1) = %v, %v; want 1", pow, err)
+	}
+}
@@ -0,0 +1,88 @@
+package intmod
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/johnkerl/goffl/pkg/intarith"
+	"github.com/johnkerl/goffl/pkg/intfactor"
+)
+
+// Sqrt returns every square root of a, in increasing order, or nil if a is not a square.
+// The modulus is factored with intfactor.Factor; roots mod p come from
+// intarith.SqrtModPrime, are lifted to each p^k by Hensel's lemma, and are combined
+// across the prime powers with CRT. There can be many roots: 0 mod p^(2j) alone has p^j.
+func (a *IntMod) Sqrt() []*IntMod {
+	finfo := intfactor.Factor(a.modulus)
+	moduli := make([]int64, finfo.NumDistinctFactors())
+	rootSets := make([][]int64, len(moduli))
+	for i := range moduli {
+		p, k := finfo.Get(i)
+		moduli[i], rootSets[i] = sqrtModPrimePower(a.Residue, p, k)
+		if len(rootSets[i]) == 0 {
+			return nil
+		}
+	}
+	solver, err := NewCRTSolver(moduli)
+	if err != nil {
+		panic("coding error detected: sqrt: " + err.Error())
+	}
+
+	var out []*IntMod
+	residues := make([]int64, len(moduli))
+	var combine func(i int)
+	combine = func(i int) {
+		if i == len(moduli) {
+			x, _ := solver.Solve(residues)
+			out = append(out, &IntMod{Residue: x.Residue, modulus: a.modulus})
+			return
+		}
+		for _, r := range rootSets[i] {
+			residues[i] = r
+			combine(i + 1)
+		}
+	}
+	combine(0)
+	slices.SortFunc(out, func(x, y *IntMod) int { return cmp.Compare(x.Residue, y.Residue) })
+	return out
+}
+
+// sqrtModPrimePower returns p^k and the roots of x^2 = a mod p^k, lifting the roots mod
+// p one power at a time. A root r with p not dividing 2r lifts uniquely by Newton's step
+// r - (r^2-a)/(2r). Any other root lifts only if r^2 = a mod the next power, and then
+// every r + t*p^i, t = 0..p-1, is a root.
+func sqrtModPrimePower(a, p int64, k int) (int64, []int64) {
+	var roots []int64
+	if r, ok := intarith.SqrtModPrime(a, p); ok {
+		roots = append(roots, r)
+		if s := (p - r) % p; s != r {
+			roots = append(roots, s)
+		}
+	}
+	pi := p
+	for i := 1; i < k && len(roots) > 0; i++ {
+		next := pi * p
+		an := intarith.MulMod(a, 1, next)
+		var lifted []int64
+		for _, r := range roots {
+			f := intarith.MulMod(r, r, next) - an
+			if f < 0 {
+				f += next
+			}
+			if r%p != 0 && p != 2 {
+				inv, _ := intarith.IntModRecip(intarith.MulMod(2, r, next), next)
+				x := intarith.MulMod(r, 1, next) - intarith.MulMod(f, inv, next)
+				if x < 0 {
+					x += next
+				}
+				lifted = append(lifted, x)
+			} else if f == 0 {
+				for t := int64(0); t < p; t++ {
+					lifted = append(lifted, r+t*pi)
+				}
+			}
+		}
+		roots, pi = lifted, next
+	}
+	return pi, roots
+}
@@ -0,0 +1,47 @@
+package intmod
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSqrt(t *testing.T) {
+	for m := int64(1); m <= 200; m++ {
+		for a := int64(0); a < m; a++ {
+			var want []int64
+			for x := int64(0); x < m; x++ {
+				if x*x%m == a {
+					want = append(want, x)
+				}
+			}
+			var got []int64
+			for _, r := range New(a, m).Sqrt() {
+				if r.Modulus() != m {
+					t.Fatalf("sqrt(%d mod %d): root has modulus %d", a, m, r.Modulus())
+				}
+				got = append(got, r.Residue)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("sqrt(%d mod %d) = %v, want %v", a, m, got, want)
+			}
+		}
+	}
+}
+
+func TestSqrtLarge(t *testing.T) {
+	// The roots of 4 are +-2 mod each of p^2, 5 and 7, so 8 in all.
+	const p = 1000003
+	m := int64(p * p * 5 * 7)
+	roots := New(4, m).Sqrt()
+	if len(roots) != 8 {
+		t.Fatalf("sqrt(4 mod %d) has %d roots, want 8", m, len(roots))
+	}
+	for _, r := range roots {
+		if !r.Mul(r).Equal(New(4, m)) {
+			t.Errorf("%d is not a root of 4 mod %d", r.Residue, m)
+		}
+	}
+	if roots := New(3, p*p).Sqrt(); roots != nil {
+		t.Errorf("3 mod p^2 is not a square; got %v", roots)
+	}
+}
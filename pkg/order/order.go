@@ -3,13 +3,17 @@ package order
 
 import (
 	"fmt"
+	"math/big"
 
+	"github.com/johnkerl/goffl/pkg/cunningham"
 	"github.com/johnkerl/goffl/pkg/f2poly"
 	"github.com/johnkerl/goffl/pkg/f2polyfactor"
 	"github.com/johnkerl/goffl/pkg/f2polymod"
+	"github.com/johnkerl/goffl/pkg/factorization"
 	"github.com/johnkerl/goffl/pkg/intarith"
 	"github.com/johnkerl/goffl/pkg/intfactor"
 	"github.com/johnkerl/goffl/pkg/intmod"
+	"github.com/johnkerl/goffl/pkg/primes"
 )
 
 // ModOrderIntMod returns the multiplicative order of a in Z/mZ.
@@ -18,25 +22,67 @@ func ModOrderIntMod(am *intmod.IntMod) (int64, error) {
 	if intarith.Gcd(a, m) != 1 {
 		return 0, fmt.Errorf("mod_order: zero or zero divisor %d mod %d", a, m)
 	}
-	phi := intfactor.Totient(m)
+	phi := m - 1
+	if !primes.IsPrime(m) {
+		phi = intfactor.Totient(m)
+	}
 	finfo := intfactor.Factor(phi)
-	phiDivisors := finfo.AllDivisors()
-	rec, err := am.Recip()
-	if err != nil {
-		return 0, fmt.Errorf("mod_order: %w", err)
+	ps := make([]int64, finfo.NumDistinctFactors())
+	for i := range ps {
+		ps[i], _ = finfo.Get(i)
 	}
-	one := am.Mul(rec)
+	return modOrder(intarith.Int64Arith{}, a, m, phi, ps)
+}
 
-	for _, e := range phiDivisors {
-		pow, err := am.Pow(e)
-		if err != nil {
-			return 0, fmt.Errorf("mod_order: %w", err)
-		}
-		if pow.Equal(one) {
-			return e, nil
+// ModOrderBigIntMod returns the multiplicative order of a in Z/mZ for moduli past int64.
+// It factors m, unless primes.IsPrimeBig says m is prime, and then phi(m) with
+// intfactor.BigFactor, so it is practical when those are within reach of Pollard rho.
+func ModOrderBigIntMod(am *intmod.BigIntMod) (*big.Int, error) {
+	a, m := am.Residue, am.Modulus()
+	if intarith.BigGcd(a, m).Cmp(big.NewInt(1)) != 0 {
+		return nil, fmt.Errorf("mod_order: zero or zero divisor %v mod %v", a, m)
+	}
+	phi := new(big.Int).Sub(m, big.NewInt(1))
+	if !primes.IsPrimeBig(m) {
+		phi = intfactor.BigTotient(m)
+	}
+	finfo := intfactor.BigFactor(phi)
+	ps := make([]*big.Int, finfo.NumDistinctFactors())
+	for i := range ps {
+		ps[i], _ = finfo.Get(i)
+	}
+	return modOrder(intarith.BigArith{}, a, m, phi, ps)
+}
+
+// modOrder returns the order of the unit a mod m, given a multiple n of it and the
+// distinct primes dividing n: each prime is divided out of n for as long as a to the
+// quotient is still 1.
+func modOrder[T any](ar intarith.Arith[T], a, m, n T, primes []T) (T, error) {
+	one := ar.Mod(ar.FromInt64(1), m)
+	ord := n
+	for _, p := range primes {
+		for ar.Sign(ar.Mod(ord, p)) == 0 {
+			e := ar.Quo(ord, p)
+			pow, err := ar.IntModExp(a, e, m)
+			if err != nil {
+				return ord, fmt.Errorf("mod_order: %w", err)
+			}
+			if ar.Cmp(pow, one) != 0 {
+				break
+			}
+			ord = e
 		}
 	}
-	return 0, fmt.Errorf("mod_order: coding error")
+	return ord, nil
+}
+
+// factorF2Totient factors phi = f2polyfactor.Totient(m). That is 2^n-1 for irreducible m
+// of degree n, which the cunningham table has; other phi are factored from scratch.
+func factorF2Totient(phi int64) *factorization.Factorization {
+	if finfo, ok := cunningham.Lookup(phi); ok {
+		return finfo
+	}
+	return intfactor.Factor(phi)
 }
 
 // ModOrderF2PolyMod returns the multiplicative order of a in F2[x]/(m).
@@ -46,8 +92,11 @@ func ModOrderF2PolyMod(am *f2polymod.F2PolyMod) (int64, error) {
 		return 0, fmt.Errorf("mod_order: zero or zero divisor mod m")
 	}
 	phi := f2polyfactor.Totient(m)
-	finfo := intfactor.Factor(phi)
-	phiDivisors := finfo.AllDivisors()
+	// A prime phi, such as a Mersenne prime 2^n-1, needs no factoring.
+	phiDivisors := []int64{1, phi}
+	if !primes.IsPrime(phi) {
+		phiDivisors = factorF2Totient(phi).AllDivisors()
+	}
 	rec, err := am.Recip()
 	if err != nil {
 		return 0, fmt.Errorf("mod_order: %w", err)
@@ -193,8 +242,10 @@ func F2PolyPrimitive(m *f2poly.F2Poly) bool {
 	}
 	rcrx := f2polymod.New(x, m)
 	phi := f2polyfactor.Totient(m)
-	finfo := intfactor.Factor(phi)
-	mpds := finfo.MaximalProperDivisors()
+	mpds := []int64{1}
+	if !primes.IsPrime(phi) {
+		mpds = factorF2Totient(phi).MaximalProperDivisors()
+	}
 
 	for _, mpd := range mpds {
 		pow, err := rcrx.Pow(int(mpd))
@@ -211,3 +262,19 @@ func F2PolyPrimitive(m *f2poly.F2Poly) bool {
 	}
 	return pow.IsOne()
 }
+
+// SparsestPrimitive returns the lowest primitive polynomial of the given degree among
+// those with the fewest nonzero coefficients, searching irreducibles weight by weight.
+func SparsestPrimitive(degree int) (*f2poly.F2Poly, error) {
+	if degree < 1 || degree > 62 {
+		return nil, fmt.Errorf("sparsest_primitive: degree must be 1..62; got %d", degree)
+	}
+	for weight := 2; weight <= degree+1; weight++ {
+		for f := range f2polyfactor.IrrOfWeight(degree, weight) {
+			if F2PolyPrimitive(f) {
+				return f, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("sparsest_primitive: coding error detected")
+}
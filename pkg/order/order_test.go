@@ -0,0 +1,77 @@
+package order
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/f2poly"
+	"github.com/johnkerl/goffl/pkg/intmod"
+)
+
+func TestModOrderIntMod(t *testing.T) {
+	tests := []struct {
+		a, m, want int64
+	}{
+		{0, 1, 1}, {1, 7, 1}, {2, 7, 3}, {3, 7, 6}, {6, 7, 2}, {3, 10, 4}, {7, 15, 4},
+	}
+	for _, tt := range tests {
+		got, err := ModOrderIntMod(intmod.New(tt.a, tt.m))
+		if err != nil || got != tt.want {
+			t.Errorf("ModOrderIntMod(%d mod %d) = %d, %v; want %d", tt.a, tt.m, got, err, tt.want)
+		}
+	}
+	if _, err := ModOrderIntMod(intmod.New(2, 4)); err == nil {
+		t.Error("2 mod 4 is a zero divisor")
+	}
+}
+
+func TestModOrderBigIntMod(t *testing.T) {
+	for m := int64(2); m < 60; m++ {
+		for a := int64(1); a < m; a++ {
+			want, err := ModOrderIntMod(intmod.New(a, m))
+			got, berr := ModOrderBigIntMod(intmod.NewBig(big.NewInt(a), big.NewInt(m)))
+			if (err == nil) != (berr == nil) || (err == nil && got.Int64() != want) {
+				t.Errorf("%d mod %d: big order %v, %v; want %d, %v", a, m, got, berr, want, err)
+			}
+		}
+	}
+	// 2 has order 127 mod the Mersenne prime 2^127-1.
+	one := big.NewInt(1)
+	m := new(big.Int).Sub(new(big.Int).Lsh(one, 127), one)
+	got, err := ModOrderBigIntMod(intmod.NewBig(big.NewInt(2), m))
+	if err != nil || got.Int64() != 127 {
+		t.Errorf("order of 2 mod 2^127-1 = %v, %v; want 127", got, err)
+	}
+}
+
+func TestPrimeShortcuts(t *testing.T) {
+	// 2^61-1 is prime, so its order needs only the factoring of 2^61-2.
+	got, err := ModOrderIntMod(intmod.New(37, 1<<61-1))
+	if err != nil || got != 1<<61-2 {
+		t.Errorf("order of 37 mod 2^61-1 = %d, %v; want 2^61-2", got, err)
+	}
+	// x^31 + x^3 + 1 is primitive and 2^31-1 is prime.
+	m := f2poly.New(1<<31 | 1<<3 | 1)
+	if !F2PolyPrimitive(m) {
+		t.Errorf("x^31+x^3+1 should be primitive")
+	}
+	if got := F2PolyPeriod(m); got != 1<<31-1 {
+		t.Errorf("period of x^31+x^3+1 = %d, want 2^31-1", got)
+	}
+}
+
+func TestF2PolyPrimitiveHighDegree(t *testing.T) {
+	// 2^59-1 = 179951 * 3203431780337 and 2^62-1 = 3 * 715827883 * 2147483647 need the
+	// rho engine; the degree-63 case also exercises F2Poly.Deriv at the top bit.
+	for _, bits := range []uint64{
+		1<<59 | 1<<7 | 1<<4 | 1<<2 | 1,
+		1<<61 | 1<<5 | 1<<2 | 1<<1 | 1,
+		1<<62 | 1<<6 | 1<<5 | 1<<3 | 1,
+		1<<63 | 1<<1 | 1,
+	} {
+		m := f2poly.New(bits)
+		if !F2PolyPrimitive(m) {
+			t.Errorf("%s should be primitive", m)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package primes
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// millerRabinBases makes Miller-Rabin deterministic for every n < 2^64 (Jim Sinclair's
+// set of seven bases).
+var millerRabinBases = []uint64{2, 325, 9375, 28178, 450775, 9780504, 1795265022}
+
+// trialPrimes are divided out before Miller-Rabin; they also cover n below 41^2.
+var trialPrimes = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+// IsPrime reports whether n is prime. Negative numbers, 0 and 1 are not.
+func IsPrime(n int64) bool {
+	return n > 1 && IsPrimeUint64(uint64(n))
+}
+
+// IsPrimeUint64 reports whether n is prime, by trial division by the primes up to 37 and
+// then Miller-Rabin with a base set known to have no strong pseudoprimes below 2^64. It is
+// exact, not probabilistic.
+func IsPrimeUint64(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	for _, p := range trialPrimes {
+		if n%p == 0 {
+			return n == p
+		}
+	}
+	if n < 41*41 {
+		return true
+	}
+	s := bits.TrailingZeros64(n - 1)
+	d := (n - 1) >> s
+	for _, a := range millerRabinBases {
+		if a %= n; a != 0 && !strongProbablePrime(n, d, s, a) {
+			return false
+		}
+	}
+	return true
+}
+
+// strongProbablePrime reports whether odd n, with n-1 = d*2^s and d odd, passes the
+// Miller-Rabin test to base a.
+func strongProbablePrime(n, d uint64, s int, a uint64) bool {
+	x := powMod64(a, d, n)
+	if x == 1 || x == n-1 {
+		return true
+	}
+	for r := 1; r < s; r++ {
+		x = mulMod64(x, x, n)
+		if x == n-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// mulMod64 returns a*b mod m through a 128-bit product, for a, b < m.
+func mulMod64(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
+
+func powMod64(x, e, m uint64) uint64 {
+	rv := uint64(1)
+	for ; e != 0; e >>= 1 {
+		if e&1 == 1 {
+			rv = mulMod64(rv, x, m)
+		}
+		x = mulMod64(x, x, m)
+	}
+	return rv
+}
+
+// IsPrimeBig reports whether n is prime. Values that fit in a uint64 use the exact
+// IsPrimeUint64; larger ones use the Baillie-PSW test, a Miller-Rabin test to base 2 and
+// a strong Lucas test, which has no known counterexample.
+func IsPrimeBig(n *big.Int) bool {
+	if n.Sign() <= 0 {
+		return false
+	}
+	if n.IsUint64() {
+		return IsPrimeUint64(n.Uint64())
+	}
+	if n.Bit(0) == 0 {
+		return false
+	}
+	nm1 := new(big.Int).Sub(n, big.NewInt(1))
+	s := nm1.TrailingZeroBits()
+	d := new(big.Int).Rsh(nm1, s)
+	x := new(big.Int).Exp(big.NewInt(2), d, n)
+	if x.Cmp(big.NewInt(1)) != 0 && x.Cmp(nm1) != 0 {
+		r := uint(1)
+		for ; r < s; r++ {
+			x.Mul(x, x).Mod(x, n)
+			if x.Cmp(nm1) == 0 {
+				break
+			}
+		}
+		if r == s {
+			return false
+		}
+	}
+	return strongLucas(n)
+}
+
+// strongLucas is the strong Lucas probable-prime test for odd n > 1 with Selfridge's
+// parameters: D the first of 5, -7, 9, -11, ... with Jacobi (D/n) = -1, P = 1 and
+// Q = (1-D)/4. With n+1 = d*2^s, d odd, n passes if U_d = 0 or V_(d*2^r) = 0 for some
+// 0 <= r < s.
+func strongLucas(n *big.Int) bool {
+	// No suitable D exists for squares, so rule them out first.
+	if r := new(big.Int).Sqrt(n); r.Mul(r, r).Cmp(n) == 0 {
+		return false
+	}
+	D := int64(5)
+	for {
+		j := big.Jacobi(big.NewInt(D), n)
+		if j == -1 {
+			break
+		}
+		if j == 0 {
+			// D shares a factor with n, which is proper unless n is |D| itself.
+			return new(big.Int).Abs(big.NewInt(D)).Cmp(n) == 0
+		}
+		if D > 0 {
+			D = -D - 2
+		} else {
+			D = -D + 2
+		}
+	}
+	bigD, bigQ := big.NewInt(D), big.NewInt((1-D)/4)
+
+	np1 := new(big.Int).Add(n, big.NewInt(1))
+	s := np1.TrailingZeroBits()
+	d := new(big.Int).Rsh(np1, s)
+
+	// halve returns x/2 mod n for odd n.
+	halve := func(x *big.Int) *big.Int {
+		if x.Bit(0) == 1 {
+			x.Add(x, n)
+		}
+		return x.Rsh(x, 1)
+	}
+	// Ladder over the bits of d from the top, keeping U_k, V_k and Q^k with P = 1.
+	U, V, Qk := big.NewInt(1), big.NewInt(1), new(big.Int).Mod(bigQ, n)
+	t := new(big.Int)
+	for i := d.BitLen() - 2; i >= 0; i-- {
+		// k -> 2k: U_2k = U_k V_k, V_2k = V_k^2 - 2Q^k.
+		U.Mul(U, V).Mod(U, n)
+		V.Mul(V, V).Sub(V, t.Lsh(Qk, 1)).Mod(V, n)
+		Qk.Mul(Qk, Qk).Mod(Qk, n)
+		if d.Bit(i) == 1 {
+			// 2k -> 2k+1: U' = (U + V)/2, V' = (D U + V)/2.
+			newU := halve(new(big.Int).Add(U, V))
+			V = halve(t.Mul(bigD, U).Add(t, V).Mod(t, n))
+			t = new(big.Int)
+			U = newU.Mod(newU, n)
+			Qk.Mul(Qk, bigQ).Mod(Qk, n)
+		}
+	}
+	if U.Sign() == 0 || V.Sign() == 0 {
+		return true
+	}
+	for r := uint(1); r < s; r++ {
+		V.Mul(V, V).Sub(V, t.Lsh(Qk, 1)).Mod(V, n)
+		if V.Sign() == 0 {
+			return true
+		}
+		Qk.Mul(Qk, Qk).Mod(Qk, n)
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package primes
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestIsPrime(t *testing.T) {
+	for n := int64(-10); n < 100000; n++ {
+		if got, want := IsPrime(n), isPrimeSlow(n); got != want {
+			t.Fatalf("IsPrime(%d) = %v, want %v", n, got, want)
+		}
+	}
+	tests := []struct {
+		n    uint64
+		want bool
+	}{
+		{3215031751, false},          // strong pseudoprime to bases 2, 3, 5, 7
+		{3825123056546413051, false}, // strong pseudoprime to bases 2 through 23
+		{341550071728321, false},     // strong pseudoprime to bases 2 through 17
+		{561, false}, {41041, false}, // Carmichael numbers
+		{1<<61 - 1, true}, {1<<31 - 1, true}, // Mersenne primes
+		{18446744073709551557, true},     // largest prime below 2^64
+		{18446744073709551615, false},    // 2^64-1
+		{4294967291 * 4294967279, false}, // product of two primes near 2^32
+		{1000000007 * 1000000009, false},
+	}
+	for _, tt := range tests {
+		if got := IsPrimeUint64(tt.n); got != tt.want {
+			t.Errorf("IsPrimeUint64(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestStrongLucas(t *testing.T) {
+	// The first strong Lucas pseudoprimes pass; other odd non-square composites fail.
+	pseudoprimes := map[int64]bool{5459: true, 5777: true, 10877: true, 16109: true, 18971: true}
+	for n := int64(3); n < 20000; n += 2 {
+		got := strongLucas(big.NewInt(n))
+		want := isPrimeSlow(n) || pseudoprimes[n]
+		if got != want {
+			t.Errorf("strongLucas(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestIsPrimeBig(t *testing.T) {
+	one := big.NewInt(1)
+	mersenne := func(e uint) *big.Int { return new(big.Int).Sub(new(big.Int).Lsh(one, e), one) }
+	tests := []struct {
+		n    *big.Int
+		want bool
+	}{
+		{mersenne(89), true}, {mersenne(107), true}, {mersenne(127), true}, {mersenne(521), true},
+		{mersenne(67), false}, {mersenne(128), false},
+		{new(big.Int).Add(new(big.Int).Lsh(one, 128), one), false},
+		{new(big.Int).Mul(mersenne(61), mersenne(89)), false},
+		{new(big.Int).Mul(mersenne(89), mersenne(89)), false},
+		{big.NewInt(0), false}, {big.NewInt(-7), false}, {big.NewInt(97), true},
+	}
+	for _, tt := range tests {
+		if got := IsPrimeBig(tt.n); got != tt.want {
+			t.Errorf("IsPrimeBig(%v) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+	rng := rand.New(rand.NewSource(1))
+	limit := new(big.Int).Lsh(one, 160)
+	for i := 0; i < 2000; i++ {
+		n := new(big.Int).Rand(rng, limit)
+		n.SetBit(n, 0, 1)
+		if got, want := IsPrimeBig(n), n.ProbablyPrime(20); got != want {
+			t.Errorf("IsPrimeBig(%v) = %v, want %v", n, got, want)
+		}
+	}
+}
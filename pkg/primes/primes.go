@@ -0,0 +1,202 @@
+// Package primes provides a segmented Sieve of Eratosthenes, iteration over the primes in
+// a range, prime counting, the n-th prime, and smallest-prime-factor tables.
+package primes
+
+import (
+	"fmt"
+	"iter"
+	"math"
+
+	"github.com/johnkerl/goffl/pkg/factorization"
+	"github.com/johnkerl/goffl/pkg/intarith"
+)
+
+// segmentSize is the number of integers sieved at a time: small enough for the segment
+// to stay in cache.
+const segmentSize = 1 << 16
+
+// Sieve returns the primes up to and including n, in increasing order.
+func Sieve(n int64) []int64 {
+	var out []int64
+	for p := range Range(2, n) {
+		out = append(out, p)
+	}
+	return out
+}
+
+// smallSieve returns the primes up to n by an unsegmented sieve, for the base primes of
+// Range.
+func smallSieve(n int64) []int64 {
+	if n < 2 {
+		return nil
+	}
+	composite := make([]bool, n+1)
+	var out []int64
+	for p := int64(2); p <= n; p++ {
+		if composite[p] {
+			continue
+		}
+		out = append(out, p)
+		for m := p * p; m <= n; m += p {
+			composite[m] = true
+		}
+	}
+	return out
+}
+
+// Range yields the primes p with lo <= p <= hi in increasing order. It sieves
+// segmentSize integers at a time with the base primes up to sqrt(hi), so memory is
+// O(sqrt(hi)) however long the range.
+func Range(lo, hi int64) iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		lo = max(lo, 2)
+		if hi < lo {
+			return
+		}
+		base := smallSieve(intarith.Isqrt(hi))
+		composite := make([]bool, segmentSize)
+		for start := lo; start <= hi; {
+			end := start + segmentSize - 1
+			if end > hi || end < start { // end < start on overflow
+				end = hi
+			}
+			clear(composite)
+			for _, p := range base {
+				if p > end/p {
+					break
+				}
+				// The first multiple of p in the segment, but not p itself.
+				m := max(p*p, (start+p-1)/p*p)
+				for ; m <= end && m >= start; m += p {
+					composite[m-start] = true
+				}
+			}
+			for i := int64(0); i <= end-start; i++ {
+				if !composite[i] && !yield(start+i) {
+					return
+				}
+			}
+			if end == hi {
+				return
+			}
+			start = end + 1
+		}
+	}
+}
+
+// PrimePi returns the number of primes up to n, by the Legendre-style recurrence over
+// the values floor(n/k) (Lucy Hedgehog's method), in O(n^(3/4)) time and O(sqrt(n))
+// space.
+func PrimePi(n int64) int64 {
+	if n < 2 {
+		return 0
+	}
+	r := intarith.Isqrt(n)
+	// small[v] = count for v <= r; large[k] = count for n/k, k <= r. Initially they hold
+	// the number of integers 2..v, and sieving by each p removes those whose least prime
+	// factor is p.
+	small := make([]int64, r+1)
+	large := make([]int64, r+1)
+	for v := int64(1); v <= r; v++ {
+		small[v] = v - 1
+		large[v] = n/v - 1
+	}
+	for p := int64(2); p <= r; p++ {
+		if small[p] == small[p-1] {
+			continue
+		}
+		sp := small[p-1]
+		p2 := p * p
+		for k := int64(1); k <= r && n/k >= p2; k++ {
+			if kp := k * p; kp <= r {
+				large[k] -= large[kp] - sp
+			} else {
+				large[k] -= small[n/kp] - sp
+			}
+		}
+		for v := r; v >= p2; v-- {
+			small[v] -= small[v/p] - sp
+		}
+	}
+	return large[1]
+}
+
+// NthPrime returns the k-th prime, counting 2 as the first. It sieves up to the bound
+// k(ln k + ln ln k), valid for k >= 6.
+func NthPrime(k int) (int64, error) {
+	if k < 1 {
+		return 0, fmt.Errorf("nth_prime: k must be positive; got %d", k)
+	}
+	bound := int64(15)
+	if k >= 6 {
+		lk := math.Log(float64(k))
+		bound = int64(float64(k)*(lk+math.Log(lk))) + 1
+	}
+	count := 0
+	for p := range Range(2, bound) {
+		count++
+		if count == k {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("nth_prime: coding error detected")
+}
+
+// SPFTable holds the smallest prime factor of every integer up to a limit, for factoring
+// many small integers in time proportional to their number of prime factors.
+type SPFTable struct {
+	spf []uint32
+}
+
+// NewSPFTable returns the table for 2..n, built by a linear sieve. n must be below 2^32.
+func NewSPFTable(n int64) (*SPFTable, error) {
+	if n < 0 || n >= 1<<32 {
+		return nil, fmt.Errorf("spf_table: limit must be 0..2^32-1; got %d", n)
+	}
+	spf := make([]uint32, n+1)
+	var ps []uint32
+	for i := int64(2); i <= n; i++ {
+		if spf[i] == 0 {
+			spf[i] = uint32(i)
+			ps = append(ps, uint32(i))
+		}
+		for _, p := range ps {
+			if p > spf[i] || i*int64(p) > n {
+				break
+			}
+			spf[i*int64(p)] = p
+		}
+	}
+	return &SPFTable{spf: spf}, nil
+}
+
+// Limit returns the largest integer in the table.
+func (t *SPFTable) Limit() int64 { return int64(len(t.spf)) - 1 }
+
+// SmallestPrimeFactor returns the least prime dividing 2 <= m <= Limit().
+func (t *SPFTable) SmallestPrimeFactor(m int64) int64 {
+	if m < 2 || m > t.Limit() {
+		panic(fmt.Sprintf("spf_table: %d out of range 2..%d", m, t.Limit()))
+	}
+	return int64(t.spf[m])
+}
+
+// Factor returns the factorization of 1 <= m <= Limit() by repeatedly dividing out the
+// smallest prime factor.
+func (t *SPFTable) Factor(m int64) *factorization.Factorization {
+	finfo := factorization.New()
+	if m == 1 {
+		finfo.InsertTrivialFactor(&m)
+		return finfo
+	}
+	for m > 1 {
+		p := t.SmallestPrimeFactor(m)
+		mult := 0
+		for m%p == 0 {
+			m /= p
+			mult++
+		}
+		finfo.InsertFactor(p, mult)
+	}
+	return finfo
+}
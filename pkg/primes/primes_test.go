@@ -0,0 +1,118 @@
+package primes
+
+import (
+	"slices"
+	"testing"
+)
+
+func isPrimeSlow(n int64) bool {
+	if n < 2 {
+		return false
+	}
+	for d := int64(2); d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSieve(t *testing.T) {
+	want := []int64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29}
+	if got := Sieve(30); !slices.Equal(got, want) {
+		t.Errorf("Sieve(30) = %v, want %v", got, want)
+	}
+	if got := Sieve(1); len(got) != 0 {
+		t.Errorf("Sieve(1) = %v", got)
+	}
+}
+
+func TestRange(t *testing.T) {
+	// Ranges straddling segment boundaries, and one far out.
+	ranges := [][2]int64{{0, 10}, {segmentSize - 50, segmentSize + 50}, {3*segmentSize + 1, 5 * segmentSize},
+		{1_000_000_000_000, 1_000_000_001_000}, {10, 9}}
+	for _, r := range ranges {
+		var want []int64
+		for n := r[0]; n <= r[1]; n++ {
+			if isPrimeSlow(n) {
+				want = append(want, n)
+			}
+		}
+		got := slices.Collect(Range(r[0], r[1]))
+		if !slices.Equal(got, want) {
+			t.Errorf("Range(%d, %d): %d primes, want %d", r[0], r[1], len(got), len(want))
+		}
+	}
+	// Stopping early.
+	for p := range Range(100, 1000) {
+		if p != 101 {
+			t.Errorf("first prime above 100 is %d", p)
+		}
+		break
+	}
+}
+
+func TestPrimePi(t *testing.T) {
+	tests := []struct{ n, want int64 }{
+		{0, 0}, {1, 0}, {2, 1}, {3, 2}, {10, 4}, {100, 25}, {1000, 168},
+		{1_000_000, 78498}, {1_000_000_000, 50847534}, {10_000_000_000, 455052511},
+	}
+	for _, tt := range tests {
+		if got := PrimePi(tt.n); got != tt.want {
+			t.Errorf("PrimePi(%d) = %d, want %d", tt.n, got, tt.want)
+		}
+	}
+	for n := int64(0); n < 2000; n++ {
+		if got, want := PrimePi(n), int64(len(Sieve(n))); got != want {
+			t.Fatalf("PrimePi(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestNthPrime(t *testing.T) {
+	tests := []struct {
+		k    int
+		want int64
+	}{
+		{1, 2}, {2, 3}, {5, 11}, {6, 13}, {100, 541}, {10000, 104729}, {1000000, 15485863},
+	}
+	for _, tt := range tests {
+		if got, err := NthPrime(tt.k); err != nil || got != tt.want {
+			t.Errorf("NthPrime(%d) = %d, %v; want %d", tt.k, got, err, tt.want)
+		}
+	}
+	for _, k := range []int{0, -1} {
+		if _, err := NthPrime(k); err == nil {
+			t.Errorf("NthPrime(%d) should fail", k)
+		}
+	}
+}
+
+func TestSPFTable(t *testing.T) {
+	for _, n := range []int64{-1, 1 << 32} {
+		if _, err := NewSPFTable(n); err == nil {
+			t.Errorf("NewSPFTable(%d) should fail", n)
+		}
+	}
+	table, err := NewSPFTable(10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for m := int64(2); m <= table.Limit(); m++ {
+		p := table.SmallestPrimeFactor(m)
+		if m%p != 0 || !isPrimeSlow(p) {
+			t.Fatalf("SmallestPrimeFactor(%d) = %d", m, p)
+		}
+		for d := int64(2); d < p; d++ {
+			if m%d == 0 {
+				t.Fatalf("SmallestPrimeFactor(%d) = %d, but %d divides it", m, p, d)
+			}
+		}
+		if got := table.Factor(m).Unfactor(); got != m {
+			t.Fatalf("Factor(%d).Unfactor() = %d", m, got)
+		}
+	}
+	if got := table.Factor(1).Unfactor(); got != 1 {
+		t.Errorf("Factor(1).Unfactor() = %d", got)
+	}
+}
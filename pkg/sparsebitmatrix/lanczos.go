@@ -0,0 +1,344 @@
+package sparsebitmatrix
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/johnkerl/goffl/pkg/bitarith"
+	"github.com/johnkerl/goffl/pkg/bitmatrix"
+	"github.com/johnkerl/goffl/pkg/bitvector"
+)
+
+// Block Lanczos after Montgomery, "A Block Lanczos Algorithm for Finding Dependencies
+// over GF(2)" (EUROCRYPT 1995), in the formulation used by msieve.
+//
+// A block is an n x 64 matrix stored as []uint64 of length n, row i in word i. A 64 x 64
+// matrix is a [64]uint64 with row i in word i, bit j being column j.
+
+// lanczosExcess is the number of surplus columns Reduce leaves for Block Lanczos.
+const lanczosExcess = 64
+
+// lanczosAttempts is how many random starts BlockLanczos tries before giving up.
+const lanczosAttempts = 4
+
+type mat64 = [64]uint64
+
+var identity64 = func() mat64 {
+	var id mat64
+	for i := range id {
+		id[i] = 1 << i
+	}
+	return id
+}()
+
+// mul64 returns a * b.
+func mul64(a, b *mat64) mat64 {
+	var c mat64
+	for i, x := range a {
+		var acc uint64
+		for x != 0 {
+			acc ^= b[bitarith.LsbPos(x)]
+			x &= x - 1
+		}
+		c[i] = acc
+	}
+	return c
+}
+
+// innerProduct returns x^T y for blocks x and y of the same length.
+func innerProduct(x, y []uint64) mat64 {
+	var c mat64
+	for i, xi := range x {
+		yi := y[i]
+		for xi != 0 {
+			c[bitarith.LsbPos(xi)] ^= yi
+			xi &= xi - 1
+		}
+	}
+	return c
+}
+
+// mulAccumulate sets y += v * a for blocks v and y and 64 x 64 a.
+func mulAccumulate(v []uint64, a *mat64, y []uint64) {
+	for i, vi := range v {
+		var acc uint64
+		for vi != 0 {
+			acc ^= a[bitarith.LsbPos(vi)]
+			vi &= vi - 1
+		}
+		y[i] ^= acc
+	}
+}
+
+// mulBlock returns m * x for a block x with NumCols() rows.
+func (m *SparseBitMatrix) mulBlock(x []uint64) []uint64 {
+	y := make([]uint64, m.numRows)
+	for j, col := range m.cols {
+		xj := x[j]
+		if xj == 0 {
+			continue
+		}
+		for _, i := range col {
+			y[i] ^= xj
+		}
+	}
+	return y
+}
+
+// mulTransBlock returns m^T * y for a block y with NumRows() rows.
+func (m *SparseBitMatrix) mulTransBlock(y []uint64) []uint64 {
+	x := make([]uint64, m.numCols)
+	for j, col := range m.cols {
+		var acc uint64
+		for _, i := range col {
+			acc ^= y[i]
+		}
+		x[j] = acc
+	}
+	return x
+}
+
+// findNonsingularSub chooses the columns S_i to keep this iteration and returns the
+// inverse Winv of the submatrix of t = V^T A V they select, along with S_i as a mask.
+// Columns not chosen last time (lastMask) get first preference, as Montgomery requires
+// that every column be chosen in this iteration or the previous one.
+func findNonsingularSub(t *mat64, lastMask uint64) (winv mat64, mask uint64, err error) {
+	var m [64][2]uint64
+	for i := range m {
+		m[i] = [2]uint64{t[i], 1 << i}
+	}
+
+	var order [64]int
+	k := 0
+	for i := 0; i < 64; i++ {
+		if lastMask&(1<<i) == 0 {
+			order[k] = i
+			k++
+		}
+	}
+	for i := 0; i < 64; i++ {
+		if lastMask&(1<<i) != 0 {
+			order[k] = i
+			k++
+		}
+	}
+
+	for i := 0; i < 64; i++ {
+		bit := uint64(1) << order[i]
+		rowI := &m[order[i]]
+
+		// Look for a pivot in the left half first.
+		found := false
+		for j := i; j < 64; j++ {
+			if m[order[j]][0]&bit != 0 {
+				*rowI, m[order[j]] = m[order[j]], *rowI
+				found = true
+				break
+			}
+		}
+		if found {
+			for j := 0; j < 64; j++ {
+				if order[j] != order[i] && m[order[j]][0]&bit != 0 {
+					m[order[j]][0] ^= rowI[0]
+					m[order[j]][1] ^= rowI[1]
+				}
+			}
+			mask |= bit
+			continue
+		}
+
+		// Otherwise this column is left out, and the right half absorbs it.
+		for j := i; j < 64; j++ {
+			if m[order[j]][1]&bit != 0 {
+				*rowI, m[order[j]] = m[order[j]], *rowI
+				found = true
+				break
+			}
+		}
+		if !found {
+			return winv, 0, fmt.Errorf("block lanczos: submatrix is not invertible")
+		}
+		for j := 0; j < 64; j++ {
+			if order[j] != order[i] && m[order[j]][1]&bit != 0 {
+				m[order[j]][0] ^= rowI[0]
+				m[order[j]][1] ^= rowI[1]
+			}
+		}
+		*rowI = [2]uint64{}
+	}
+
+	if mask == 0 || mask|lastMask != ^uint64(0) {
+		return winv, 0, fmt.Errorf("block lanczos: not all columns used")
+	}
+	for i := range m {
+		winv[i] = m[i][1]
+	}
+	return winv, mask, nil
+}
+
+// BlockLanczos returns kernel vectors of m as the rows of a dense matrix, or nil if none
+// were found. It is meant for large matrices with somewhat more columns than rows, such
+// as those left by Reduce with a positive excess; the result is typically a few dozen
+// independent vectors, not a full basis. Use KernelBasis to have the matrix reduced
+// first and small cases solved densely.
+func (m *SparseBitMatrix) BlockLanczos() (*bitmatrix.BitMatrix, error) {
+	var err error
+	for attempt := 0; attempt < lanczosAttempts; attempt++ {
+		var basis *bitmatrix.BitMatrix
+		basis, err = m.blockLanczosOnce()
+		if err == nil {
+			return basis, nil
+		}
+	}
+	return nil, err
+}
+
+func (m *SparseBitMatrix) blockLanczosOnce() (*bitmatrix.BitMatrix, error) {
+	n := m.numCols
+	if n == 0 || m.numRows == 0 {
+		return nil, fmt.Errorf("block lanczos: matrix is %d x %d", m.numRows, n)
+	}
+	mulSym := func(x []uint64) []uint64 { return m.mulTransBlock(m.mulBlock(x)) }
+
+	// The iteration solves A x = v0 with A = m^T m and v0 = A y for random y. Starting
+	// x at y means that over GF(2) it ends at y + A^-1 A y, which A sends to zero.
+	x := make([]uint64, n)
+	for i := range x {
+		x[i] = rand.Uint64()
+	}
+	v0 := mulSym(x)
+
+	// Index 0 is the current iteration, 1 and 2 the two before it.
+	var v [3][]uint64
+	v[0] = v0
+	v[1] = make([]uint64, n)
+	v[2] = make([]uint64, n)
+	var winv [3]mat64
+	var vtAv, vtA2v [2]mat64
+	mask1 := ^uint64(0)
+
+	maxIterations := n/60 + 100
+	for iter := 0; ; iter++ {
+		if iter > maxIterations {
+			return nil, fmt.Errorf("block lanczos: no convergence after %d iterations", iter)
+		}
+		av := mulSym(v[0])
+		vtAv[0] = innerProduct(v[0], av)
+		vtA2v[0] = innerProduct(av, av)
+
+		done := true
+		for _, w := range vtAv[0] {
+			if w != 0 {
+				done = false
+				break
+			}
+		}
+		if done {
+			break
+		}
+
+		var mask0 uint64
+		var err error
+		winv[0], mask0, err = findNonsingularSub(&vtAv[0], mask1)
+		if err != nil {
+			return nil, err
+		}
+
+		// D = I - Winv_0 (vtA2v_0 S S^T + vtAv_0)
+		var d mat64
+		for i := range d {
+			d[i] = (vtA2v[0][i] & mask0) ^ vtAv[0][i]
+		}
+		d = mul64(&winv[0], &d)
+		for i := range d {
+			d[i] ^= identity64[i]
+		}
+
+		// E = Winv_1 vtAv_0 S S^T
+		e := mul64(&winv[1], &vtAv[0])
+		for i := range e {
+			e[i] &= mask0
+		}
+
+		// F = Winv_2 (I - vtAv_1 Winv_1) (vtA2v_1 S_1 S_1^T + vtAv_1) S S^T
+		f := mul64(&vtAv[1], &winv[1])
+		for i := range f {
+			f[i] ^= identity64[i]
+		}
+		f = mul64(&winv[2], &f)
+		var f2 mat64
+		for i := range f2 {
+			f2[i] = ((vtA2v[1][i] & mask1) ^ vtAv[1][i]) & mask0
+		}
+		f = mul64(&f, &f2)
+
+		vnext := av
+		for i := range vnext {
+			vnext[i] &= mask0
+		}
+		mulAccumulate(v[0], &d, vnext)
+		mulAccumulate(v[1], &e, vnext)
+		mulAccumulate(v[2], &f, vnext)
+
+		// x += V_0 Winv_0 V_0^T v0
+		t := innerProduct(v[0], v0)
+		t = mul64(&winv[0], &t)
+		mulAccumulate(v[0], &t, x)
+
+		v[0], v[1], v[2] = vnext, v[0], v[1]
+		winv[2], winv[1] = winv[1], winv[0]
+		vtAv[1], vtA2v[1] = vtAv[0], vtA2v[0]
+		mask1 = mask0
+	}
+
+	return m.combineCandidates(x, v[0])
+}
+
+// combineCandidates finds combinations of the 128 columns of blocks x and vm that m
+// sends to zero. Most columns of x are already kernel vectors of m^T m; elimination on
+// their images under m sorts out the ones that are kernel vectors of m itself.
+func (m *SparseBitMatrix) combineCandidates(x, vm []uint64) (*bitmatrix.BitMatrix, error) {
+	n := m.numCols
+	ax := m.mulBlock(x)
+	avm := m.mulBlock(vm)
+
+	// Row k is [image of candidate k | candidate k]. After reduction, rows with a pivot
+	// among the candidate columns have a zero image.
+	aug, err := bitmatrix.New(128, m.numRows+n)
+	if err != nil {
+		return nil, err
+	}
+	for k := 0; k < 128; k++ {
+		img, cand := ax, x
+		bit := uint64(1) << (k & 63)
+		if k >= 64 {
+			img, cand = avm, vm
+		}
+		row := aug.Rows[k]
+		for i, w := range img {
+			if w&bit != 0 {
+				row.Set(i, 1)
+			}
+		}
+		for j, w := range cand {
+			if w&bit != 0 {
+				row.Set(m.numRows+j, 1)
+			}
+		}
+	}
+
+	rref := aug.ReducedRowEchelonForm()
+	var vectors []*bitvector.BitVector
+	for i, col := range rref.Pivots {
+		if col >= m.numRows {
+			v, _ := rref.R.Rows[i].Slice(m.numRows, m.numRows+n)
+			vectors = append(vectors, v)
+		}
+	}
+	if len(vectors) == 0 {
+		return nil, nil
+	}
+	basis, _ := bitmatrix.New(len(vectors), n)
+	copy(basis.Rows, vectors)
+	return basis, nil
+}
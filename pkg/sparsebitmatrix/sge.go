@@ -0,0 +1,202 @@
+package sparsebitmatrix
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/johnkerl/goffl/pkg/bitmatrix"
+	"github.com/johnkerl/goffl/pkg/bitvector"
+)
+
+// Reduction is the result of structured Gaussian elimination: a smaller matrix together
+// with the map taking its kernel vectors back to kernel vectors of the original.
+type Reduction struct {
+	Matrix *SparseBitMatrix
+	// origCols[j] lists the original columns that column j of Matrix stands for.
+	origCols    [][]int
+	numOrigCols int
+}
+
+// Reduce performs structured Gaussian elimination, repeating until nothing changes:
+//
+//   - A row with a single 1, in column j, forces x_j = 0, so column j is deleted.
+//   - A row with two 1s, in columns j and k, forces x_j = x_k, so column k is added into
+//     column j and deleted; j then stands for both.
+//   - Rows left empty are deleted.
+//
+// These steps preserve the kernel exactly. If excess >= 0, the heaviest columns are also
+// deleted until at most excess more columns than rows remain. That shrinks the kernel
+// but still leaves at least excess dimensions of it, and is what Block Lanczos wants.
+func (m *SparseBitMatrix) Reduce(excess int) *Reduction {
+	cols := make([][]int, m.numCols)
+	origCols := make([][]int, m.numCols)
+	alive := make([]bool, m.numCols)
+	for j := range cols {
+		cols[j] = slices.Clone(m.cols[j])
+		origCols[j] = []int{j}
+		alive[j] = true
+	}
+	numAlive := m.numCols
+
+	rowCols := make([][]int, m.numRows)
+	for {
+		changed := false
+
+		for i := range rowCols {
+			rowCols[i] = rowCols[i][:0]
+		}
+		for j, col := range cols {
+			if alive[j] {
+				for _, i := range col {
+					rowCols[i] = append(rowCols[i], j)
+				}
+			}
+		}
+
+		// Within one pass, rowCols goes stale as soon as a column changes, so each column
+		// takes part in at most one step per pass.
+		touched := make([]bool, m.numCols)
+		for _, rc := range rowCols {
+			switch len(rc) {
+			case 1:
+				j := rc[0]
+				if touched[j] {
+					continue
+				}
+				alive[j], touched[j] = false, true
+				numAlive--
+				changed = true
+			case 2:
+				j, k := rc[0], rc[1]
+				if touched[j] || touched[k] {
+					continue
+				}
+				cols[j] = symmetricDifference(cols[j], cols[k])
+				origCols[j] = append(origCols[j], origCols[k]...)
+				alive[k] = false
+				touched[j], touched[k] = true, true
+				numAlive--
+				changed = true
+			}
+		}
+
+		if !changed && excess >= 0 {
+			numRows := 0
+			for i := range rowCols {
+				if len(rowCols[i]) > 0 {
+					numRows++
+				}
+			}
+			if surplus := numAlive - numRows - excess; surplus > 0 {
+				heavy := make([]int, 0, numAlive)
+				for j := range cols {
+					if alive[j] {
+						heavy = append(heavy, j)
+					}
+				}
+				slices.SortStableFunc(heavy, func(a, b int) int { return len(cols[b]) - len(cols[a]) })
+				for _, j := range heavy[:surplus] {
+					alive[j] = false
+				}
+				numAlive -= surplus
+				changed = true
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	// Renumber the surviving rows and columns.
+	rowIndex := make([]int, m.numRows)
+	for i := range rowIndex {
+		rowIndex[i] = -1
+	}
+	numRows := 0
+	for j, col := range cols {
+		if !alive[j] {
+			continue
+		}
+		for _, i := range col {
+			if rowIndex[i] < 0 {
+				rowIndex[i] = numRows
+				numRows++
+			}
+		}
+	}
+	r := &Reduction{
+		Matrix:      &SparseBitMatrix{numRows: numRows, numCols: numAlive, cols: make([][]int, 0, numAlive)},
+		origCols:    make([][]int, 0, numAlive),
+		numOrigCols: m.numCols,
+	}
+	for j, col := range cols {
+		if !alive[j] {
+			continue
+		}
+		newCol := make([]int, len(col))
+		for k, i := range col {
+			newCol[k] = rowIndex[i]
+		}
+		slices.Sort(newCol)
+		r.Matrix.cols = append(r.Matrix.cols, newCol)
+		r.origCols = append(r.origCols, origCols[j])
+	}
+	return r
+}
+
+// symmetricDifference returns the sorted union minus intersection of sorted a and b,
+// which is a + b as columns over GF(2).
+func symmetricDifference(a, b []int) []int {
+	out := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			out = append(out, a[i])
+			i++
+		case a[i] > b[j]:
+			out = append(out, b[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	out = append(out, a[i:]...)
+	return append(out, b[j:]...)
+}
+
+// Lift maps a kernel vector of r.Matrix to a kernel vector of the original matrix.
+func (r *Reduction) Lift(v *bitvector.BitVector) (*bitvector.BitVector, error) {
+	if v.NumBits() != r.Matrix.numCols {
+		return nil, fmt.Errorf("Reduction lift: vector length %d != %d columns", v.NumBits(), r.Matrix.numCols)
+	}
+	rv, err := bitvector.New(r.numOrigCols)
+	if err != nil {
+		return nil, err
+	}
+	for j := range v.SetPositions() {
+		for _, k := range r.origCols[j] {
+			rv.Set(k, 1)
+		}
+	}
+	return rv, nil
+}
+
+// LiftBasis applies Lift to each row of a kernel basis of r.Matrix. Lifting is injective,
+// so independent rows stay independent.
+func (r *Reduction) LiftBasis(basis *bitmatrix.BitMatrix) (*bitmatrix.BitMatrix, error) {
+	out, err := bitmatrix.New(basis.NumRows(), r.numOrigCols)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < basis.NumRows(); i++ {
+		v, err := r.Lift(basis.Row(i))
+		if err != nil {
+			return nil, err
+		}
+		out.Rows[i] = v
+	}
+	return out, nil
+}
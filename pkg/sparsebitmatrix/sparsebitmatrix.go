@@ -0,0 +1,192 @@
+// Package sparsebitmatrix provides sparse matrices over GF(2) for systems too large to
+// hold densely, such as the relation matrices from factoring and index calculus. Kernel
+// vectors are found by structured Gaussian elimination followed by Block Lanczos.
+package sparsebitmatrix
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/johnkerl/goffl/pkg/bitmatrix"
+	"github.com/johnkerl/goffl/pkg/bitvector"
+)
+
+// SparseBitMatrix is a matrix over GF(2) stored by column: each column is the sorted
+// list of rows holding a 1. Column-major storage suits both products needed by Block
+// Lanczos and the column merges done by structured Gaussian elimination.
+type SparseBitMatrix struct {
+	numRows, numCols int
+	cols             [][]int
+}
+
+func New(numRows, numCols int) (*SparseBitMatrix, error) {
+	if numRows <= 0 || numCols <= 0 {
+		return nil, fmt.Errorf("SparseBitMatrix: dimensions must be > 0; got %d x %d", numRows, numCols)
+	}
+	return &SparseBitMatrix{numRows: numRows, numCols: numCols, cols: make([][]int, numCols)}, nil
+}
+
+func (m *SparseBitMatrix) NumRows() int { return m.numRows }
+func (m *SparseBitMatrix) NumCols() int { return m.numCols }
+
+// NumNonzeros returns the number of 1 entries.
+func (m *SparseBitMatrix) NumNonzeros() int {
+	n := 0
+	for _, c := range m.cols {
+		n += len(c)
+	}
+	return n
+}
+
+func (m *SparseBitMatrix) checkBounds(i, j int) error {
+	if i < 0 || i >= m.numRows || j < 0 || j >= m.numCols {
+		return fmt.Errorf("index (%d, %d) out of bounds 0..%d x 0..%d", i, j, m.numRows-1, m.numCols-1)
+	}
+	return nil
+}
+
+func (m *SparseBitMatrix) Get(i, j int) (int, error) {
+	if err := m.checkBounds(i, j); err != nil {
+		return 0, err
+	}
+	if _, found := slices.BinarySearch(m.cols[j], i); found {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m *SparseBitMatrix) Set(i, j int, val int) error {
+	if err := m.checkBounds(i, j); err != nil {
+		return err
+	}
+	k, found := slices.BinarySearch(m.cols[j], i)
+	if val&1 == 1 && !found {
+		m.cols[j] = slices.Insert(m.cols[j], k, i)
+	} else if val&1 == 0 && found {
+		m.cols[j] = slices.Delete(m.cols[j], k, k+1)
+	}
+	return nil
+}
+
+func (m *SparseBitMatrix) ToggleElement(i, j int) error {
+	v, err := m.Get(i, j)
+	if err != nil {
+		return err
+	}
+	return m.Set(i, j, v^1)
+}
+
+// SetColumn replaces column j with ones at the given rows. A row listed twice cancels,
+// as in a sum over GF(2).
+func (m *SparseBitMatrix) SetColumn(j int, rows []int) error {
+	if j < 0 || j >= m.numCols {
+		return fmt.Errorf("column %d out of bounds 0..%d", j, m.numCols-1)
+	}
+	sorted := slices.Clone(rows)
+	slices.Sort(sorted)
+	col := sorted[:0]
+	for _, i := range sorted {
+		if i < 0 || i >= m.numRows {
+			return fmt.Errorf("row %d out of bounds 0..%d", i, m.numRows-1)
+		}
+		if n := len(col); n > 0 && col[n-1] == i {
+			col = col[:n-1]
+		} else {
+			col = append(col, i)
+		}
+	}
+	m.cols[j] = col
+	return nil
+}
+
+// Column returns the rows holding a 1 in column j, in increasing order.
+func (m *SparseBitMatrix) Column(j int) []int { return slices.Clone(m.cols[j]) }
+
+// FromBitMatrix returns a sparse copy of a dense matrix.
+func FromBitMatrix(d *bitmatrix.BitMatrix) *SparseBitMatrix {
+	m := &SparseBitMatrix{numRows: d.NumRows(), numCols: d.NumCols(), cols: make([][]int, d.NumCols())}
+	for i := 0; i < d.NumRows(); i++ {
+		for j := range d.Row(i).SetPositions() {
+			m.cols[j] = append(m.cols[j], i)
+		}
+	}
+	return m
+}
+
+// ToBitMatrix returns a dense copy of the matrix.
+func (m *SparseBitMatrix) ToBitMatrix() (*bitmatrix.BitMatrix, error) {
+	d, err := bitmatrix.New(m.numRows, m.numCols)
+	if err != nil {
+		return nil, err
+	}
+	for j, col := range m.cols {
+		for _, i := range col {
+			d.Rows[i].Set(j, 1)
+		}
+	}
+	return d, nil
+}
+
+// MulVec returns m * v, where v has NumCols() bits.
+func (m *SparseBitMatrix) MulVec(v *bitvector.BitVector) (*bitvector.BitVector, error) {
+	if v.NumBits() != m.numCols {
+		return nil, fmt.Errorf("SparseBitMatrix mulvec: vector length %d != %d columns", v.NumBits(), m.numCols)
+	}
+	rv, _ := bitvector.New(m.numRows)
+	for j := range v.SetPositions() {
+		for _, i := range m.cols[j] {
+			rv.ToggleElement(i)
+		}
+	}
+	return rv, nil
+}
+
+// KernelBasis returns kernel vectors of m as the rows of a dense matrix, or nil if none
+// were found, matching bitmatrix.KernelBasis. Structured Gaussian elimination first
+// shrinks the matrix without changing its kernel. If what remains is small it is
+// solved densely and the result is a full basis. Otherwise Block Lanczos runs on it
+// after heavy columns are pruned, typically returning about 60 independent kernel vectors.
+func (m *SparseBitMatrix) KernelBasis() (*bitmatrix.BitMatrix, error) {
+	red := m.Reduce(-1)
+	r := red.Matrix
+
+	var basis *bitmatrix.BitMatrix
+	var err error
+	if r.numCols <= denseThreshold {
+		basis, err = r.denseKernelBasis()
+	} else {
+		pruned := r.Reduce(lanczosExcess)
+		if pruned.Matrix.numCols <= denseThreshold {
+			basis, err = pruned.Matrix.denseKernelBasis()
+		} else {
+			basis, err = pruned.Matrix.BlockLanczos()
+		}
+		if err == nil && basis != nil {
+			basis, err = pruned.LiftBasis(basis)
+		}
+	}
+	if err != nil || basis == nil {
+		return nil, err
+	}
+	return red.LiftBasis(basis)
+}
+
+// denseThreshold is the column count at or below which KernelBasis solves the reduced
+// matrix densely rather than with Block Lanczos.
+const denseThreshold = 1024
+
+// denseKernelBasis returns the full kernel basis by dense elimination, allowing for the
+// empty shapes that structured Gaussian elimination can leave behind.
+func (m *SparseBitMatrix) denseKernelBasis() (*bitmatrix.BitMatrix, error) {
+	if m.numCols == 0 {
+		return nil, nil
+	}
+	if m.numRows == 0 {
+		return bitmatrix.Identity(m.numCols)
+	}
+	d, err := m.ToBitMatrix()
+	if err != nil {
+		return nil, err
+	}
+	return d.KernelBasis()
+}
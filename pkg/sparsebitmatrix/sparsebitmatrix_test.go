@@ -0,0 +1,156 @@
+package sparsebitmatrix
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/johnkerl/goffl/pkg/bitmatrix"
+)
+
+// randomSparse returns a matrix with about weight ones per column, from a fixed seed.
+func randomSparse(t *testing.T, numRows, numCols, weight int, seed int64) *SparseBitMatrix {
+	t.Helper()
+	rng := rand.New(rand.NewSource(seed))
+	m, err := New(numRows, numCols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j := 0; j < numCols; j++ {
+		rows := make([]int, weight)
+		for k := range rows {
+			// Skew toward low rows, like small primes in a factor base.
+			rows[k] = int(float64(numRows) * rng.Float64() * rng.Float64())
+		}
+		if err := m.SetColumn(j, rows); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func checkKernelVectors(t *testing.T, m *SparseBitMatrix, basis *bitmatrix.BitMatrix) {
+	t.Helper()
+	if basis == nil {
+		t.Fatal("no kernel vectors found")
+	}
+	if basis.NumCols() != m.NumCols() {
+		t.Fatalf("kernel vectors have %d bits, want %d", basis.NumCols(), m.NumCols())
+	}
+	if got := basis.Rank(); got != basis.NumRows() {
+		t.Errorf("kernel vectors have rank %d, want %d", got, basis.NumRows())
+	}
+	for k := 0; k < basis.NumRows(); k++ {
+		if basis.Row(k).IsZero() {
+			t.Fatalf("kernel vector %d is zero", k)
+		}
+		img, _ := m.MulVec(basis.Row(k))
+		if !img.IsZero() {
+			t.Fatalf("kernel vector %d is not in the kernel", k)
+		}
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	m, _ := New(4, 5)
+	m.Set(2, 3, 1)
+	m.ToggleElement(0, 3)
+	m.ToggleElement(2, 3)
+	if got := m.Column(3); len(got) != 1 || got[0] != 0 {
+		t.Errorf("Column(3) = %v, want [0]", got)
+	}
+	if v, _ := m.Get(0, 3); v != 1 {
+		t.Error("Get(0, 3) should be 1")
+	}
+	if err := m.Set(4, 0, 1); err == nil {
+		t.Error("Set out of bounds should fail")
+	}
+	m.SetColumn(1, []int{3, 1, 3, 2})
+	if got := m.Column(1); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("Column(1) = %v, want [1 2]", got)
+	}
+	if got := m.NumNonzeros(); got != 3 {
+		t.Errorf("NumNonzeros() = %d, want 3", got)
+	}
+}
+
+func TestDenseRoundTrip(t *testing.T) {
+	d, _ := bitmatrix.Random(30, 70)
+	m := FromBitMatrix(d)
+	back, err := m.ToBitMatrix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !back.Equal(d) {
+		t.Error("FromBitMatrix/ToBitMatrix round trip failed")
+	}
+}
+
+func TestReducePreservesKernel(t *testing.T) {
+	m := randomSparse(t, 200, 260, 4, 1)
+	red := m.Reduce(-1)
+	if red.Matrix.NumCols() >= m.NumCols() {
+		t.Errorf("Reduce did not shrink: %d columns", red.Matrix.NumCols())
+	}
+	d, _ := m.ToBitMatrix()
+	wantDim := d.NumCols() - d.Rank()
+
+	basis, err := m.KernelBasis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkKernelVectors(t, m, basis)
+	if basis.NumRows() != wantDim {
+		t.Errorf("kernel dimension %d, want %d", basis.NumRows(), wantDim)
+	}
+}
+
+func TestReduceHeavyPruning(t *testing.T) {
+	m := randomSparse(t, 300, 600, 6, 2)
+	red := m.Reduce(20)
+	r := red.Matrix
+	if r.NumCols() > r.NumRows()+20 {
+		t.Errorf("pruned to %d x %d, want at most 20 surplus columns", r.NumRows(), r.NumCols())
+	}
+}
+
+func TestKernelBasisSmallCases(t *testing.T) {
+	// A single row with two ones: the kernel is spanned by e_2 and e_0 + e_1.
+	m, _ := New(1, 3)
+	m.Set(0, 0, 1)
+	m.Set(0, 1, 1)
+	basis, err := m.KernelBasis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkKernelVectors(t, m, basis)
+	if basis.NumRows() != 2 {
+		t.Errorf("kernel dimension %d, want 2", basis.NumRows())
+	}
+
+	// Identity has trivial kernel.
+	id, _ := bitmatrix.Identity(10)
+	if basis, _ := FromBitMatrix(id).KernelBasis(); basis != nil {
+		t.Error("identity should have trivial kernel")
+	}
+}
+
+func TestBlockLanczos(t *testing.T) {
+	m := randomSparse(t, 1900, 2000, 12, 3)
+	basis, err := m.BlockLanczos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkKernelVectors(t, m, basis)
+	if basis.NumRows() < 32 {
+		t.Errorf("only %d kernel vectors found", basis.NumRows())
+	}
+}
+
+func TestKernelBasisLarge(t *testing.T) {
+	m := randomSparse(t, 3000, 3100, 14, 4)
+	basis, err := m.KernelBasis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkKernelVectors(t, m, basis)
+}